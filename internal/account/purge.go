@@ -0,0 +1,144 @@
+package account
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"ships-backend/internal/blob"
+	"ships-backend/internal/export"
+	"ships-backend/internal/models"
+)
+
+// Purger hard-deletes accounts whose deletion grace period is over
+type Purger struct {
+	DB       *mongo.Database
+	Blobs    blob.Store
+	Interval time.Duration
+}
+
+func NewPurger(db *mongo.Database, blobs blob.Store) *Purger {
+	return &Purger{DB: db, Blobs: blobs, Interval: time.Hour}
+}
+
+// Run purges due accounts every Interval until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := p.PurgeDue(ctx); err != nil {
+			log.Printf("account purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("purged %d deleted account(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDue removes every account whose purgeAt has passed and returns how many were removed
+func (p *Purger) PurgeDue(ctx context.Context) (int, error) {
+	cursor, err := p.DB.Collection("users").Find(ctx, bson.M{
+		"deletedAt": bson.M{"$exists": true},
+		"purgeAt":   bson.M{"$lte": time.Now()},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var due []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &due); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, u := range due {
+		if err := PurgeUser(ctx, p.DB, p.Blobs, u.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// PurgeUser deletes the user and everything that references them. The users document goes
+// last so a failure part-way is simply retried on the next run.
+func PurgeUser(ctx context.Context, db *mongo.Database, blobs blob.Store, userID primitive.ObjectID) error {
+	// Archives live on disk, so they go through the export package
+	if err := export.RemoveForUser(ctx, db, userID); err != nil {
+		return err
+	}
+
+	// Photo bytes before their user_photos records, which hold the keys
+	var photos []models.UserPhoto
+	cursor, err := db.Collection("user_photos").Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(ctx, &photos); err != nil {
+		return err
+	}
+	for _, p := range photos {
+		for _, key := range p.BlobKeys() {
+			if err := blobs.Delete(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Conversations are removed as a whole, including the other side's messages
+	cursor, err = db.Collection("matches").Find(ctx, bson.M{
+		"$or": []bson.M{{"user1": userID}, {"user2": userID}},
+	})
+	if err != nil {
+		return err
+	}
+	var matches []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &matches); err != nil {
+		return err
+	}
+	matchIDs := make([]primitive.ObjectID, 0, len(matches))
+	for _, m := range matches {
+		matchIDs = append(matchIDs, m.ID)
+	}
+
+	cascade := []struct {
+		collection string
+		filter     bson.M
+	}{
+		{"messages", bson.M{"$or": []bson.M{{"matchId": bson.M{"$in": matchIDs}}, {"fromUser": userID}}}},
+		{"matches", bson.M{"_id": bson.M{"$in": matchIDs}}},
+		{"swipes", bson.M{"$or": []bson.M{{"fromUser": userID}, {"toUser": userID}}}},
+		{"likes", bson.M{"$or": []bson.M{{"fromUser": userID}, {"toUser": userID}}}},
+		{"seen", bson.M{"$or": []bson.M{{"userId": userID}, {"seenUser": userID}}}},
+		{"crossed_paths", bson.M{"$or": []bson.M{{"user1": userID}, {"user2": userID}}}},
+		{"blocks", bson.M{"$or": []bson.M{{"blocker": userID}, {"blocked": userID}}}},
+		{"user_photos", bson.M{"userId": userID}},
+		{"refresh_tokens", bson.M{"userId": userID}},
+		{"auth_sessions", bson.M{"userId": userID}},
+		{"password_resets", bson.M{"userId": userID}},
+		{"login_codes", bson.M{"userId": userID}},
+		{"login_events", bson.M{"userId": userID}},
+		{"users", bson.M{"_id": userID}},
+	}
+
+	for _, c := range cascade {
+		if _, err := db.Collection(c.collection).DeleteMany(ctx, c.filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package account
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Resumer ends pauses whose auto-resume time has passed. Discovery already ignores expired
+// pauses, this just makes the stored state match.
+type Resumer struct {
+	DB       *mongo.Database
+	Interval time.Duration
+}
+
+func NewResumer(db *mongo.Database) *Resumer {
+	return &Resumer{DB: db, Interval: 5 * time.Minute}
+}
+
+// Run resumes due accounts every Interval until ctx is cancelled
+func (r *Resumer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.ResumeDue(ctx); err != nil {
+			log.Printf("scheduled resume failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ResumeDue clears every pause whose pausedUntil has passed and returns how many were cleared
+func (r *Resumer) ResumeDue(ctx context.Context) (int64, error) {
+	res, err := r.DB.Collection("users").UpdateMany(ctx,
+		bson.M{"pausedUntil": bson.M{"$lte": time.Now()}},
+		bson.M{"$unset": bson.M{"pausedAt": "", "pausedUntil": ""}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
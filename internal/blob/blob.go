@@ -0,0 +1,70 @@
+// Package blob stores binary objects such as photos outside of MongoDB documents
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps objects under slash-separated keys like "photos/<userId>/<photoId>".
+// Drivers: FSStore, GridFSStore and S3Store.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns ErrNotFound for a missing key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete is a no-op for a missing key
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStoreFromEnv picks a driver from BLOB_DRIVER (fs, gridfs or s3)
+func NewStoreFromEnv(db *mongo.Database) (Store, error) {
+	switch driver := getEnv("BLOB_DRIVER", "fs"); driver {
+	case "fs":
+		return &FSStore{Dir: getEnv("BLOB_DIR", "blobs")}, nil
+	case "gridfs":
+		return &GridFSStore{DB: db, Bucket: getEnv("BLOB_GRIDFS_BUCKET", "blobs")}, nil
+	case "s3":
+		s := &S3Store{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    getEnv("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+		}
+		if s.Endpoint == "" || s.Bucket == "" || s.AccessKey == "" || s.SecretKey == "" {
+			return nil, fmt.Errorf("BLOB_DRIVER=s3 requires S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY")
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown BLOB_DRIVER %q", driver)
+	}
+}
+
+// validKey rejects keys that could escape a directory or bucket prefix
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
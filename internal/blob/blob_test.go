@@ -0,0 +1,61 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// testRoundTrip runs the Store contract against one driver
+func testRoundTrip(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+	key := "photos/user 1/photo+1.jpg"
+
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get before Put: err = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Put(ctx, key, []byte("first"), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	// Putting the same key again replaces the object
+	if err := s.Put(ctx, key, []byte("second"), "image/jpeg"); err != nil {
+		t.Fatalf("second Put: %v", err)
+	}
+	if got := readBlob(t, s, key); !bytes.Equal(got, []byte("second")) {
+		t.Fatalf("Get = %q, want %q", got, "second")
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing key: %v", err)
+	}
+
+	for _, bad := range []string{"", "/abs", "photos/../x", "photos//x", `photos\x`} {
+		if err := s.Put(ctx, bad, []byte("x"), ""); err == nil {
+			t.Errorf("Put(%q) accepted an invalid key", bad)
+		}
+	}
+}
+
+func readBlob(t *testing.T, s Store, key string) []byte {
+	t.Helper()
+	rc, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("reading %q: %v", key, err)
+	}
+	return data
+}
@@ -0,0 +1,24 @@
+package blob
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	testRoundTrip(t, &FSStore{Dir: dir})
+
+	// No temp files are left behind next to the objects
+	leftovers, err := filepath.Glob(filepath.Join(dir, "photos", "*", ".upload-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) > 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "photos", "user 1")); err != nil {
+		t.Errorf("object directory: %v", err)
+	}
+}
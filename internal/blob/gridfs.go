@@ -0,0 +1,99 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore keeps objects in a GridFS bucket of DB, chunked so the 16 MB document limit doesn't
+// apply. The key is the GridFS filename.
+type GridFSStore struct {
+	DB     *mongo.Database
+	Bucket string
+}
+
+// bucket opens the GridFS bucket for one operation. Deadlines are per bucket, so buckets are not
+// shared between requests.
+func (s *GridFSStore) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	b, err := gridfs.NewBucket(s.DB, options.GridFSBucket().SetName(s.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = b.SetReadDeadline(deadline)
+		_ = b.SetWriteDeadline(deadline)
+	}
+	return b, nil
+}
+
+// Put replaces any earlier object with the same key
+func (s *GridFSStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	b, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := b.UploadFromStream(key, bytes.NewReader(data),
+		options.GridFSUpload().SetMetadata(bson.M{"contentType": contentType}))
+	if err != nil {
+		return err
+	}
+	return s.deleteFiles(ctx, b, bson.M{"filename": key, "_id": bson.M{"$ne": id}})
+}
+
+func (s *GridFSStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	b, err := s.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := b.OpenDownloadStreamByName(key)
+	if err == gridfs.ErrFileNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (s *GridFSStore) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	b, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	return s.deleteFiles(ctx, b, bson.M{"filename": key})
+}
+
+func (s *GridFSStore) deleteFiles(ctx context.Context, b *gridfs.Bucket, filter bson.M) error {
+	cursor, err := b.FindContext(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var files []struct {
+		ID any `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &files); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := b.DeleteContext(ctx, f.ID); err != nil && err != gridfs.ErrFileNotFound {
+			return err
+		}
+	}
+	return nil
+}
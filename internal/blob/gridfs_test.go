@@ -0,0 +1,38 @@
+package blob
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestGridFSStoreRoundTrip needs a MongoDB server; set MONGO_TEST_URI to run it. It works in a
+// throwaway database that is dropped afterwards.
+func TestGridFSStoreRoundTrip(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	db := client.Database("blob_test_" + primitive.NewObjectID().Hex())
+	defer db.Drop(context.Background())
+
+	testRoundTrip(t, &GridFSStore{DB: db, Bucket: "blobs"})
+}
@@ -0,0 +1,64 @@
+// Package catalog holds the server-defined option lists clients pick from: enumerated profile
+// values, profile prompts and the interest taxonomy
+package catalog
+
+// Field visibility settings
+const (
+	VisibleEveryone = "everyone"
+	VisibleMatches  = "matches"
+	VisiblePrivate  = "private"
+)
+
+var Visibilities = []string{VisibleEveryone, VisibleMatches, VisiblePrivate}
+
+var (
+	EducationLevels   = []string{"high_school", "trade_school", "in_college", "bachelors", "masters", "doctorate"}
+	RelationshipGoals = []string{"long_term", "long_term_open", "short_term", "casual", "friends", "not_sure"}
+	DrinkingOptions   = []string{"never", "socially", "often"}
+	SmokingOptions    = []string{"never", "socially", "often"}
+	KidsOptions       = []string{"have", "want", "dont_want", "open", "not_sure"}
+)
+
+// EnumField is a profile field with a fixed set of values that discovery can filter on
+type EnumField struct {
+	Path       string   `json:"-"`      // bson path on the user document
+	Visibility string   `json:"-"`      // key in User.Visibility that guards it
+	Values     []string `json:"values"` // nil means any language code
+}
+
+// Filters are the fields DiscoveryPreferences.Filters may use, by filter name
+var Filters = map[string]EnumField{
+	"education":        {Path: "education", Visibility: "education", Values: EducationLevels},
+	"relationshipGoal": {Path: "relationshipGoal", Visibility: "relationshipGoal", Values: RelationshipGoals},
+	"drinking":         {Path: "lifestyle.drinking", Visibility: "lifestyle", Values: DrinkingOptions},
+	"smoking":          {Path: "lifestyle.smoking", Visibility: "lifestyle", Values: SmokingOptions},
+	"kids":             {Path: "lifestyle.kids", Visibility: "lifestyle", Values: KidsOptions},
+	"languages":        {Path: "languages", Visibility: "languages"},
+}
+
+// Prompt is a question users can answer on their profile
+type Prompt struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+var Prompts = []Prompt{
+	{ID: "perfect_sunday", Text: "My perfect Sunday"},
+	{ID: "two_truths", Text: "Two truths and a lie"},
+	{ID: "green_flag", Text: "A green flag I look for"},
+	{ID: "unpopular_opinion", Text: "My most unpopular opinion"},
+	{ID: "travel_story", Text: "The best trip I've taken"},
+	{ID: "geek_out", Text: "I geek out on"},
+	{ID: "first_date", Text: "The ideal first date"},
+	{ID: "simple_pleasures", Text: "My simple pleasures"},
+}
+
+// PromptByID looks up a prompt in the catalog
+func PromptByID(id string) (Prompt, bool) {
+	for _, p := range Prompts {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Prompt{}, false
+}
@@ -0,0 +1,197 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is used when a name is missing in the requested locale
+const DefaultLocale = "en"
+
+// Locales the catalog has names for
+var Locales = []string{"en", "es", "pt", "fr", "de"}
+
+// Category groups interests in the picker
+type Category struct {
+	ID    string
+	Names map[string]string
+}
+
+// Interest is one canonical interest. Users store the ID; Names and Aliases are only used for
+// display and for mapping free text onto the catalog.
+type Interest struct {
+	ID       string
+	Category string
+	Names    map[string]string
+	Aliases  []string
+}
+
+var Categories = []Category{
+	{ID: "games", Names: map[string]string{"en": "Games", "es": "Juegos", "pt": "Jogos", "fr": "Jeux", "de": "Spiele"}},
+	{ID: "music", Names: map[string]string{"en": "Music", "es": "Música", "pt": "Música", "fr": "Musique", "de": "Musik"}},
+	{ID: "outdoors", Names: map[string]string{"en": "Outdoors", "es": "Aire libre", "pt": "Ar livre", "fr": "Plein air", "de": "Draußen"}},
+	{ID: "sports", Names: map[string]string{"en": "Sports", "es": "Deportes", "pt": "Esportes", "fr": "Sports", "de": "Sport"}},
+	{ID: "arts", Names: map[string]string{"en": "Arts & culture", "es": "Arte y cultura", "pt": "Arte e cultura", "fr": "Arts et culture", "de": "Kunst & Kultur"}},
+	{ID: "food", Names: map[string]string{"en": "Food & drink", "es": "Comida y bebida", "pt": "Comida e bebida", "fr": "Cuisine et boissons", "de": "Essen & Trinken"}},
+	{ID: "lifestyle", Names: map[string]string{"en": "Lifestyle", "es": "Estilo de vida", "pt": "Estilo de vida", "fr": "Style de vie", "de": "Lifestyle"}},
+}
+
+var Interests = []Interest{
+	{ID: "video_games", Category: "games", Aliases: []string{"gaming", "games", "gamer", "videogames"},
+		Names: map[string]string{"en": "Video games", "es": "Videojuegos", "pt": "Videogames", "fr": "Jeux vidéo", "de": "Videospiele"}},
+	{ID: "board_games", Category: "games", Aliases: []string{"boardgames", "tabletop"},
+		Names: map[string]string{"en": "Board games", "es": "Juegos de mesa", "pt": "Jogos de tabuleiro", "fr": "Jeux de société", "de": "Brettspiele"}},
+	{ID: "anime", Category: "games", Aliases: []string{"manga"},
+		Names: map[string]string{"en": "Anime", "es": "Anime", "pt": "Anime", "fr": "Anime", "de": "Anime"}},
+	{ID: "rock", Category: "music", Aliases: []string{"rock music", "metal"},
+		Names: map[string]string{"en": "Rock", "es": "Rock", "pt": "Rock", "fr": "Rock", "de": "Rock"}},
+	{ID: "pop", Category: "music", Aliases: []string{"pop music"},
+		Names: map[string]string{"en": "Pop", "es": "Pop", "pt": "Pop", "fr": "Pop", "de": "Pop"}},
+	{ID: "hip_hop", Category: "music", Aliases: []string{"hiphop", "rap"},
+		Names: map[string]string{"en": "Hip hop", "es": "Hip hop", "pt": "Hip hop", "fr": "Hip-hop", "de": "Hip-Hop"}},
+	{ID: "electronic", Category: "music", Aliases: []string{"edm", "techno", "house"},
+		Names: map[string]string{"en": "Electronic music", "es": "Música electrónica", "pt": "Música eletrônica", "fr": "Musique électronique", "de": "Elektronische Musik"}},
+	{ID: "concerts", Category: "music", Aliases: []string{"live music", "festivals"},
+		Names: map[string]string{"en": "Concerts", "es": "Conciertos", "pt": "Shows", "fr": "Concerts", "de": "Konzerte"}},
+	{ID: "hiking", Category: "outdoors", Aliases: []string{"trekking", "trails"},
+		Names: map[string]string{"en": "Hiking", "es": "Senderismo", "pt": "Trilhas", "fr": "Randonnée", "de": "Wandern"}},
+	{ID: "camping", Category: "outdoors",
+		Names: map[string]string{"en": "Camping", "es": "Acampar", "pt": "Acampamento", "fr": "Camping", "de": "Camping"}},
+	{ID: "beach", Category: "outdoors", Aliases: []string{"surfing", "surf"},
+		Names: map[string]string{"en": "Beach", "es": "Playa", "pt": "Praia", "fr": "Plage", "de": "Strand"}},
+	{ID: "travel", Category: "outdoors", Aliases: []string{"traveling", "travelling"},
+		Names: map[string]string{"en": "Travel", "es": "Viajar", "pt": "Viagens", "fr": "Voyages", "de": "Reisen"}},
+	{ID: "running", Category: "sports", Aliases: []string{"jogging", "marathon"},
+		Names: map[string]string{"en": "Running", "es": "Correr", "pt": "Corrida", "fr": "Course à pied", "de": "Laufen"}},
+	{ID: "gym", Category: "sports", Aliases: []string{"fitness", "workout", "weightlifting"},
+		Names: map[string]string{"en": "Gym", "es": "Gimnasio", "pt": "Academia", "fr": "Salle de sport", "de": "Fitnessstudio"}},
+	{ID: "football", Category: "sports", Aliases: []string{"soccer", "futebol", "fútbol"},
+		Names: map[string]string{"en": "Football", "es": "Fútbol", "pt": "Futebol", "fr": "Football", "de": "Fußball"}},
+	{ID: "yoga", Category: "sports",
+		Names: map[string]string{"en": "Yoga", "es": "Yoga", "pt": "Ioga", "fr": "Yoga", "de": "Yoga"}},
+	{ID: "cycling", Category: "sports", Aliases: []string{"biking", "bike"},
+		Names: map[string]string{"en": "Cycling", "es": "Ciclismo", "pt": "Ciclismo", "fr": "Vélo", "de": "Radfahren"}},
+	{ID: "movies", Category: "arts", Aliases: []string{"film", "films", "cinema"},
+		Names: map[string]string{"en": "Movies", "es": "Cine", "pt": "Filmes", "fr": "Cinéma", "de": "Filme"}},
+	{ID: "reading", Category: "arts", Aliases: []string{"books", "literature"},
+		Names: map[string]string{"en": "Reading", "es": "Lectura", "pt": "Leitura", "fr": "Lecture", "de": "Lesen"}},
+	{ID: "photography", Category: "arts", Aliases: []string{"photos"},
+		Names: map[string]string{"en": "Photography", "es": "Fotografía", "pt": "Fotografia", "fr": "Photographie", "de": "Fotografie"}},
+	{ID: "art", Category: "arts", Aliases: []string{"painting", "drawing", "museums"},
+		Names: map[string]string{"en": "Art", "es": "Arte", "pt": "Arte", "fr": "Art", "de": "Kunst"}},
+	{ID: "theatre", Category: "arts", Aliases: []string{"theater"},
+		Names: map[string]string{"en": "Theatre", "es": "Teatro", "pt": "Teatro", "fr": "Théâtre", "de": "Theater"}},
+	{ID: "cooking", Category: "food", Aliases: []string{"baking", "chef"},
+		Names: map[string]string{"en": "Cooking", "es": "Cocinar", "pt": "Cozinhar", "fr": "Cuisine", "de": "Kochen"}},
+	{ID: "coffee", Category: "food",
+		Names: map[string]string{"en": "Coffee", "es": "Café", "pt": "Café", "fr": "Café", "de": "Kaffee"}},
+	{ID: "wine", Category: "food",
+		Names: map[string]string{"en": "Wine", "es": "Vino", "pt": "Vinho", "fr": "Vin", "de": "Wein"}},
+	{ID: "vegan", Category: "food", Aliases: []string{"vegetarian", "plant based"},
+		Names: map[string]string{"en": "Vegan food", "es": "Comida vegana", "pt": "Comida vegana", "fr": "Cuisine végane", "de": "Vegane Küche"}},
+	{ID: "pets", Category: "lifestyle", Aliases: []string{"dogs", "cats", "animals"},
+		Names: map[string]string{"en": "Pets", "es": "Mascotas", "pt": "Pets", "fr": "Animaux", "de": "Haustiere"}},
+	{ID: "volunteering", Category: "lifestyle", Aliases: []string{"charity"},
+		Names: map[string]string{"en": "Volunteering", "es": "Voluntariado", "pt": "Voluntariado", "fr": "Bénévolat", "de": "Ehrenamt"}},
+	{ID: "meditation", Category: "lifestyle", Aliases: []string{"mindfulness"},
+		Names: map[string]string{"en": "Meditation", "es": "Meditación", "pt": "Meditação", "fr": "Méditation", "de": "Meditation"}},
+	{ID: "tech", Category: "lifestyle", Aliases: []string{"technology", "programming", "coding"},
+		Names: map[string]string{"en": "Technology", "es": "Tecnología", "pt": "Tecnologia", "fr": "Technologie", "de": "Technik"}},
+}
+
+var (
+	interestsByID = map[string]Interest{}
+	// lower-cased names, aliases and IDs (with spaces for underscores) → ID
+	interestLookup = map[string]string{}
+)
+
+func init() {
+	for _, in := range Interests {
+		interestsByID[in.ID] = in
+		interestLookup[normalizeInterest(in.ID)] = in.ID
+		for _, name := range in.Names {
+			interestLookup[normalizeInterest(name)] = in.ID
+		}
+		for _, alias := range in.Aliases {
+			interestLookup[normalizeInterest(alias)] = in.ID
+		}
+	}
+}
+
+func normalizeInterest(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer("_", " ", "-", " ").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// InterestByID looks up a catalog interest
+func InterestByID(id string) (Interest, bool) {
+	in, ok := interestsByID[id]
+	return in, ok
+}
+
+// MatchInterest maps free text such as "Gaming" or "video games" to a catalog ID
+func MatchInterest(text string) (string, bool) {
+	id, ok := interestLookup[normalizeInterest(text)]
+	return id, ok
+}
+
+// Name returns the interest's display name in locale, falling back to DefaultLocale
+func (in Interest) Name(locale string) string {
+	if name, ok := in.Names[locale]; ok {
+		return name
+	}
+	return in.Names[DefaultLocale]
+}
+
+// Name returns the category's display name in locale, falling back to DefaultLocale
+func (c Category) Name(locale string) string {
+	if name, ok := c.Names[locale]; ok {
+		return name
+	}
+	return c.Names[DefaultLocale]
+}
+
+// ResolveLocale picks a supported locale from an explicit choice or an Accept-Language header
+func ResolveLocale(explicit, acceptLanguage string) string {
+	candidates := []string{explicit}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		candidates = append(candidates, strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+	}
+	for _, c := range candidates {
+		primary := strings.ToLower(strings.SplitN(c, "-", 2)[0])
+		for _, l := range Locales {
+			if primary == l {
+				return l
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+type LocalizedInterest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type LocalizedCategory struct {
+	ID        string              `json:"id"`
+	Name      string              `json:"name"`
+	Interests []LocalizedInterest `json:"interests"`
+}
+
+// LocalizedInterests returns the catalog grouped by category with names in locale, sorted by name
+func LocalizedInterests(locale string) []LocalizedCategory {
+	out := make([]LocalizedCategory, 0, len(Categories))
+	for _, c := range Categories {
+		lc := LocalizedCategory{ID: c.ID, Name: c.Name(locale), Interests: []LocalizedInterest{}}
+		for _, in := range Interests {
+			if in.Category == c.ID {
+				lc.Interests = append(lc.Interests, LocalizedInterest{ID: in.ID, Name: in.Name(locale)})
+			}
+		}
+		sort.Slice(lc.Interests, func(i, j int) bool { return lc.Interests[i].Name < lc.Interests[j].Name })
+		out = append(out, lc)
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+// Package completeness scores how filled-in a profile is. The score is stored on the user as
+// "completeness" so discovery can leave out half-empty profiles.
+package completeness
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"ships-backend/internal/models"
+)
+
+const (
+	// DefaultMinScore is enough for a photo and a location
+	DefaultMinScore = 40
+	MinPhotos       = 3
+	MinInterests    = 3
+)
+
+// Step is one item of the onboarding checklist. Points add up to 100.
+type Step struct {
+	ID     string `json:"id"`
+	Label  string `json:"label"`
+	Points int    `json:"points"`
+}
+
+var Steps = []Step{
+	{ID: "photo", Label: "Add a profile photo", Points: 25},
+	{ID: "photos", Label: "Add at least " + strconv.Itoa(MinPhotos) + " photos", Points: 10},
+	{ID: "bio", Label: "Write a short bio", Points: 15},
+	{ID: "interests", Label: "Pick at least " + strconv.Itoa(MinInterests) + " interests", Points: 10},
+	{ID: "gender", Label: "Tell us your gender", Points: 5},
+	{ID: "prompt", Label: "Answer a profile prompt", Points: 10},
+	{ID: "location", Label: "Share your location", Points: 15},
+	{ID: "email_verified", Label: "Verify your email", Points: 10},
+}
+
+type Result struct {
+	Score   int    `json:"score"`
+	Missing []Step `json:"missing"`
+}
+
+// MinScore reads PROFILE_MIN_COMPLETENESS (0-100); profiles below it are left out of discovery
+func MinScore() int {
+	if n, err := strconv.Atoi(os.Getenv("PROFILE_MIN_COMPLETENESS")); err == nil && n >= 0 && n <= 100 {
+		return n
+	}
+	return DefaultMinScore
+}
+
+// Score checks u against Steps; photos is the number of approved photos in user_photos
+func Score(u models.User, photos int64) Result {
+	done := map[string]bool{
+		"photo":          photos >= 1,
+		"photos":         photos >= MinPhotos,
+		"bio":            u.Bio != "",
+		"interests":      len(u.Interests) >= MinInterests,
+		"gender":         u.Gender != "",
+		"prompt":         len(u.Prompts) > 0,
+		"location":       u.HasLocation(),
+		"email_verified": u.EmailVerified,
+	}
+
+	res := Result{Missing: []Step{}}
+	for _, s := range Steps {
+		if done[s.ID] {
+			res.Score += s.Points
+		} else {
+			res.Missing = append(res.Missing, s)
+		}
+	}
+	return res
+}
+
+// Store scores an already loaded user and saves the score
+func Store(ctx context.Context, db *mongo.Database, u models.User) (Result, error) {
+	photos, err := db.Collection("user_photos").CountDocuments(ctx, bson.M{"userId": u.ID, "status": models.PhotoApproved})
+	if err != nil {
+		return Result{}, err
+	}
+	res := Score(u, photos)
+	// updatedAt is left alone: it versions what the user edited
+	if _, err := db.Collection("users").UpdateByID(ctx, u.ID, bson.M{"$set": bson.M{"completeness": res.Score}}); err != nil {
+		return Result{}, err
+	}
+	return res, nil
+}
+
+// Recompute loads the user and stores a fresh score. Call it after anything that changes a step.
+func Recompute(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (Result, error) {
+	var u models.User
+	if err := db.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&u); err != nil {
+		return Result{}, err
+	}
+	return Store(ctx, db, u)
+}
@@ -65,6 +65,99 @@ func EnsureIndexes(db *mongo.Database) error {
 		Options: options.Index().SetUnique(true),
 	}
 
+	refreshTokenIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "familyId", Value: 1}},
+		},
+		{
+			// Let Mongo drop refresh tokens once they expire
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	sessionIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	passwordResetIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	loginCodeIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "linkTokenHash", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "email", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	oidcStateIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "stateHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	loginEventIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+		{
+			// Login history is kept for a year
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(365 * 24 * 60 * 60),
+		},
+	}
+
+	// No TTL here: the archive on disk has to be removed along with the record
+	exportIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "downloadTokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"downloadTokenHash": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "expiresAt", Value: 1}},
+		},
+	}
+
+	// One local user per external account
+	identityIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}),
+	}
+
 	_, err := db.Collection("likes").Indexes().CreateOne(ctx, likesIndex)
 	if err != nil {
 		return err
@@ -75,5 +168,95 @@ func EnsureIndexes(db *mongo.Database) error {
 		return err
 	}
 
+	// Tokens from before hashing have no tokenHash. Refresh only looks tokens up by hash, so they
+	// can't be used anymore, and they would make the unique index fail to build.
+	_, err = db.Collection("refresh_tokens").DeleteMany(ctx, bson.M{"tokenHash": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("refresh_tokens").Indexes().CreateMany(ctx, refreshTokenIndexes)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("auth_sessions").Indexes().CreateMany(ctx, sessionIndexes)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("password_resets").Indexes().CreateMany(ctx, passwordResetIndexes)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("login_codes").Indexes().CreateMany(ctx, loginCodeIndexes)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("oidc_states").Indexes().CreateMany(ctx, oidcStateIndexes)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("users").Indexes().CreateOne(ctx, identityIndex)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("login_events").Indexes().CreateMany(ctx, loginEventIndexes)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("exports").Indexes().CreateMany(ctx, exportIndexes)
+	if err != nil {
+		return err
+	}
+
+	// The purger and resumer look up accounts by purgeAt and pausedUntil
+	_, err = db.Collection("users").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "purgeAt", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "pausedUntil", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// One block per pair and direction; lookups go both ways
+	_, err = db.Collection("blocks").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "blocker", Value: 1}, {Key: "blocked", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "blocked", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// The moderation queue, oldest first; flagged accounts for moderators
+	_, err = db.Collection("user_photos").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "flaggedAt", Value: -1}},
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,234 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/blob"
+	"ships-backend/internal/models"
+)
+
+// The archive only holds what the user produced themselves. Records shared with another
+// person (matches, crossed paths) are reduced to the user's side, without the other profile.
+
+type swipeRecord struct {
+	ToUser    primitive.ObjectID `json:"toUser"`
+	Action    models.SwipeAction `json:"action"`
+	Source    string             `json:"source,omitempty"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+type matchRecord struct {
+	ID        primitive.ObjectID `json:"id"`
+	CreatedAt time.Time          `json:"createdAt"`
+	ClosedAt  *time.Time         `json:"closedAt,omitempty"`
+}
+
+type messageRecord struct {
+	MatchID   primitive.ObjectID `json:"matchId"`
+	Content   string             `json:"content"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+type crossedRecord struct {
+	Timestamp    time.Time       `json:"timestamp"`
+	Location     models.Location `json:"location"`
+	TimesCrossed int             `json:"timesCrossed"`
+}
+
+type photoRecord struct {
+	File      string    `json:"file"`
+	MimeType  string    `json:"mimeType"`
+	Order     int       `json:"order"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WriteArchive writes the user's personal data as a zip of JSON files plus their photos
+func WriteArchive(ctx context.Context, db *mongo.Database, blobs blob.Store, userID primitive.ObjectID, out io.Writer) error {
+	zw := zip.NewWriter(out)
+
+	var user models.User
+	if err := db.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "profile.json", user); err != nil {
+		return err
+	}
+	if len(user.LegacyInterests) > 0 {
+		if err := writeJSON(zw, "legacy_interests.json", user.LegacyInterests); err != nil {
+			return err
+		}
+	}
+	// Not part of the user's JSON, see models.User
+	if user.Preferences != nil {
+		if err := writeJSON(zw, "preferences.json", user.Preferences); err != nil {
+			return err
+		}
+	}
+
+	var swipes []models.Swipe
+	if err := findAll(ctx, db, "swipes", bson.M{"fromUser": userID}, &swipes); err != nil {
+		return err
+	}
+	swipeRecords := make([]swipeRecord, 0, len(swipes))
+	for _, s := range swipes {
+		swipeRecords = append(swipeRecords, swipeRecord{ToUser: s.ToUser, Action: s.Action, Source: s.Source, CreatedAt: s.CreatedAt})
+	}
+	if err := writeJSON(zw, "swipes.json", swipeRecords); err != nil {
+		return err
+	}
+
+	var matches []models.Match
+	if err := findAll(ctx, db, "matches", bson.M{"$or": []bson.M{{"user1": userID}, {"user2": userID}}}, &matches); err != nil {
+		return err
+	}
+	matchRecords := make([]matchRecord, 0, len(matches))
+	for _, m := range matches {
+		matchRecords = append(matchRecords, matchRecord{ID: m.ID, CreatedAt: m.CreatedAt, ClosedAt: m.ClosedAt})
+	}
+	if err := writeJSON(zw, "matches.json", matchRecords); err != nil {
+		return err
+	}
+
+	// Only messages the user sent; replies belong to the other person
+	var messages []models.Message
+	if err := findAll(ctx, db, "messages", bson.M{"fromUser": userID}, &messages); err != nil {
+		return err
+	}
+	messageRecords := make([]messageRecord, 0, len(messages))
+	for _, m := range messages {
+		messageRecords = append(messageRecords, messageRecord{MatchID: m.MatchID, Content: m.Content, CreatedAt: m.CreatedAt})
+	}
+	if err := writeJSON(zw, "messages.json", messageRecords); err != nil {
+		return err
+	}
+
+	var crossed []models.CrossedPath
+	if err := findAll(ctx, db, "crossed_paths", bson.M{"$or": []bson.M{{"user1": userID}, {"user2": userID}}}, &crossed); err != nil {
+		return err
+	}
+	crossedRecords := make([]crossedRecord, 0, len(crossed))
+	for _, c := range crossed {
+		crossedRecords = append(crossedRecords, crossedRecord{Timestamp: c.Timestamp, Location: c.Location, TimesCrossed: c.TimesCrossed})
+	}
+	if err := writeJSON(zw, "crossed_paths.json", crossedRecords); err != nil {
+		return err
+	}
+
+	// Only blocks the user made; who blocked them is the other person's data
+	blocks := []models.Block{}
+	if err := findAll(ctx, db, "blocks", bson.M{"blocker": userID}, &blocks); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "blocks.json", blocks); err != nil {
+		return err
+	}
+
+	sessions := []models.AuthSession{}
+	if err := findAll(ctx, db, "auth_sessions", bson.M{"userId": userID}, &sessions); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "sessions.json", sessions); err != nil {
+		return err
+	}
+
+	logins := []models.LoginEvent{}
+	if err := findAll(ctx, db, "login_events", bson.M{"userId": userID}, &logins); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "login_history.json", logins); err != nil {
+		return err
+	}
+
+	if err := writePhotos(ctx, db, blobs, zw, userID); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writePhotos(ctx context.Context, db *mongo.Database, blobs blob.Store, zw *zip.Writer, userID primitive.ObjectID) error {
+	cursor, err := db.Collection("user_photos").Find(ctx, bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "order", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	// Streamed from the blob store one at a time
+	records := []photoRecord{}
+	for cursor.Next(ctx) {
+		var photo models.UserPhoto
+		if err := cursor.Decode(&photo); err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("photos/%02d-%s%s", photo.Order, photo.ID.Hex(), extensionFor(photo.MimeType))
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if err := copyBlob(ctx, blobs, photo.Key, f); err != nil {
+			return fmt.Errorf("photo %s: %w", photo.ID.Hex(), err)
+		}
+		records = append(records, photoRecord{File: name, MimeType: photo.MimeType, Order: photo.Order, Status: photo.Status, CreatedAt: photo.CreatedAt})
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return writeJSON(zw, "photos.json", records)
+}
+
+func copyBlob(ctx context.Context, blobs blob.Store, key string, w io.Writer) error {
+	r, err := blobs.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func findAll(ctx context.Context, db *mongo.Database, collection string, filter bson.M, out any) error {
+	cursor, err := db.Collection(collection).Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, out)
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func extensionFor(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".bin"
+	}
+}
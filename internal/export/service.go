@@ -0,0 +1,183 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"ships-backend/internal/blob"
+	"ships-backend/internal/models"
+)
+
+const (
+	buildTimeout = 10 * time.Minute
+	// A pending export older than this was lost (e.g. a restart mid-build) and no longer blocks a new one
+	staleAfter = time.Hour
+)
+
+// Service builds export archives in the background and keeps them on disk until they expire
+type Service struct {
+	DB    *mongo.Database
+	Blobs blob.Store // where the photos are read from
+	Dir   string
+	TTL   time.Duration
+}
+
+func NewService(db *mongo.Database, blobs blob.Store, dir string, ttl time.Duration) (*Service, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Service{DB: db, Blobs: blobs, Dir: dir, TTL: ttl}, nil
+}
+
+// NewServiceFromEnv reads EXPORT_DIR (default "exports") and EXPORT_TTL (default 72h)
+func NewServiceFromEnv(db *mongo.Database, blobs blob.Store) (*Service, error) {
+	dir := os.Getenv("EXPORT_DIR")
+	if dir == "" {
+		dir = "exports"
+	}
+
+	ttl := 72 * time.Hour
+	if v := os.Getenv("EXPORT_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EXPORT_TTL: %w", err)
+		}
+		ttl = d
+	}
+
+	return NewService(db, blobs, dir, ttl)
+}
+
+// Start queues a new export for the user, or returns the one already being built
+func (s *Service) Start(ctx context.Context, userID primitive.ObjectID) (models.DataExport, error) {
+	exports := s.DB.Collection("exports")
+	now := time.Now()
+
+	var export models.DataExport
+	err := exports.FindOne(ctx, bson.M{
+		"userId":    userID,
+		"status":    models.ExportPending,
+		"createdAt": bson.M{"$gt": now.Add(-staleAfter)},
+	}).Decode(&export)
+	if err == nil {
+		return export, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return export, err
+	}
+
+	export = models.DataExport{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Status:    models.ExportPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.TTL),
+	}
+	if _, err := exports.InsertOne(ctx, export); err != nil {
+		return export, err
+	}
+
+	go s.build(export)
+
+	return export, nil
+}
+
+func (s *Service) build(export models.DataExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout)
+	defer cancel()
+
+	path := filepath.Join(s.Dir, export.ID.Hex()+".zip")
+	size, err := s.writeFile(ctx, export.UserID, path)
+
+	now := time.Now()
+	update := bson.M{"status": models.ExportReady, "file": path, "size": size, "completedAt": now}
+	if err != nil {
+		log.Printf("export %s failed: %v", export.ID.Hex(), err)
+		_ = os.Remove(path)
+		update = bson.M{"status": models.ExportFailed, "error": err.Error(), "completedAt": now}
+	}
+
+	if _, err := s.DB.Collection("exports").UpdateByID(ctx, export.ID, bson.M{"$set": update}); err != nil {
+		log.Printf("export %s status update failed: %v", export.ID.Hex(), err)
+	}
+}
+
+// writeFile writes to a temp file first so a half-written archive is never served
+func (s *Service) writeFile(ctx context.Context, userID primitive.ObjectID, path string) (int64, error) {
+	tmp, err := os.CreateTemp(s.Dir, "export-*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := WriteArchive(ctx, s.DB, s.Blobs, userID, tmp); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	return info.Size(), os.Rename(tmp.Name(), path)
+}
+
+// Run removes expired archives every hour until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if err := s.remove(ctx, bson.M{"expiresAt": bson.M{"$lte": time.Now()}}); err != nil {
+			log.Printf("export cleanup failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RemoveForUser deletes all of the user's exports, archives included
+func RemoveForUser(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	return (&Service{DB: db}).remove(ctx, bson.M{"userId": userID})
+}
+
+func (s *Service) remove(ctx context.Context, filter bson.M) error {
+	exports := s.DB.Collection("exports")
+
+	cursor, err := exports.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var found []models.DataExport
+	if err := cursor.All(ctx, &found); err != nil {
+		return err
+	}
+
+	for _, export := range found {
+		if export.File != "" {
+			if err := os.Remove(export.File); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if _, err := exports.DeleteOne(ctx, bson.M{"_id": export.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
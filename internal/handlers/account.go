@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+	"ships-backend/internal/ws"
+)
+
+const (
+	defaultDeletionGrace = 30 * 24 * time.Hour
+	// Accounts without a password prove who they are by having signed in recently
+	recentLoginWindow = 10 * time.Minute
+)
+
+type DeleteAccountRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type DeleteAccountResponse struct {
+	Message string    `json:"message"`
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+// deletionGrace reads ACCOUNT_DELETION_GRACE (e.g. "720h"); until it passes the data can still be recovered by support
+func deletionGrace() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE")); err == nil && d >= 0 {
+		return d
+	}
+	return defaultDeletionGrace
+}
+
+// reauthenticate checks the password, or a fresh session for accounts that have none, plus the second factor when enabled
+func (h *AuthHandler) reauthenticate(ctx context.Context, user models.User, sessionID string, req DeleteAccountRequest) bool {
+	if user.Password != "" {
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+			return false
+		}
+	} else {
+		sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
+		if err != nil {
+			return false
+		}
+		count, err := h.db.Collection("auth_sessions").CountDocuments(ctx, bson.M{
+			"_id":       sessionObjID,
+			"userId":    user.ID,
+			"createdAt": bson.M{"$gte": time.Now().Add(-recentLoginWindow)},
+		})
+		if err != nil || count == 0 {
+			return false
+		}
+	}
+
+	if user.TwoFactorEnabled {
+		return h.verifySecondFactor(ctx, user, req.Code, req.RecoveryCode)
+	}
+	return true
+}
+
+// DeleteAccountHandler handles DELETE /api/auth/account. The account disappears immediately;
+// the purger removes the data once the grace period is over.
+func (h *AuthHandler) DeleteAccountHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		sessionID := r.Context().Value(middlewares.SessionIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		var req DeleteAccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := h.db.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		key := "delete:" + userID
+		if decision := h.accountGuard.Check(key); !decision.Allowed {
+			respondThrottled(w, decision, ErrCodeTooManyAttempts,
+				"Too many failed attempts, try again later",
+				"account deletion throttled for user "+userID,
+			)
+			return
+		}
+
+		if !h.reauthenticate(ctx, user, sessionID, req) {
+			h.accountGuard.Fail(key)
+			msg := "Invalid password or code"
+			if user.Password == "" {
+				msg = "Please sign in again before deleting your account"
+			}
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				msg,
+				"account deletion re-authentication failed for user "+userID,
+			)
+			return
+		}
+		h.accountGuard.Succeed(key)
+
+		now := time.Now()
+		purgeAt := now.Add(deletionGrace())
+
+		res, err := h.db.Collection("users").UpdateOne(ctx,
+			bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}},
+			bson.M{
+				"$set":   bson.M{"deletedAt": now, "purgeAt": purgeAt, "updatedAt": now},
+				"$unset": bson.M{"verifyToken": "", "verifyTokenExpiresAt": ""},
+			},
+		)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not delete account.",
+				err.Error(),
+			)
+			return
+		}
+		if res.ModifiedCount == 0 {
+			http.Error(w, "Account already deleted", http.StatusConflict)
+			return
+		}
+
+		if err := revokeAllSessions(ctx, h.db, objID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not delete account.",
+				err.Error(),
+			)
+			return
+		}
+
+		// Outstanding sign-in links must not bring the account back
+		_, _ = h.db.Collection("login_codes").UpdateMany(ctx,
+			bson.M{"userId": objID, "usedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"usedAt": now}},
+		)
+		_, _ = h.db.Collection("password_resets").UpdateMany(ctx,
+			bson.M{"userId": objID, "usedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"usedAt": now}},
+		)
+
+		h.closeMatches(ctx, objID, now)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(DeleteAccountResponse{
+			Message: "Your account has been deleted",
+			PurgeAt: purgeAt,
+		})
+	}
+}
+
+// closeMatches ends every open conversation of the user and tells the other side over WebSocket
+func (h *AuthHandler) closeMatches(ctx context.Context, userID primitive.ObjectID, now time.Time) {
+	closeMatchesWhere(ctx, h.db, h.wsManager, userID,
+		bson.M{"$or": []bson.M{{"user1": userID}, {"user2": userID}}}, now)
+}
+
+// closeMatchesWhere closes the open matches of closer that filter selects and tells the other side
+func closeMatchesWhere(ctx context.Context, db *mongo.Database, wsManager *ws.Manager, closer primitive.ObjectID, filter bson.M, now time.Time) {
+	matchCol := db.Collection("matches")
+	filter["closedAt"] = bson.M{"$exists": false}
+
+	cursor, err := matchCol.Find(ctx, filter)
+	if err != nil {
+		return
+	}
+	var matches []models.Match
+	if err := cursor.All(ctx, &matches); err != nil {
+		return
+	}
+
+	_, _ = matchCol.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"closedAt": now}})
+
+	for _, match := range matches {
+		other := match.User1
+		if other == closer {
+			other = match.User2
+		}
+		wsManager.SendTo(other.Hex(), models.ChatMessagePayload{
+			Type:     "match_closed",
+			MatchID:  match.ID,
+			FromUser: closer,
+			Text:     "This conversation has been closed",
+			Time:     now,
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+)
+
+// blockedIDs returns everyone userID blocked or was blocked by
+func blockedIDs(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := db.Collection("blocks").Find(ctx, bson.M{"$or": []bson.M{
+		{"blocker": userID},
+		{"blocked": userID},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	var blocks []models.Block
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Blocker == userID {
+			ids = append(ids, b.Blocked)
+		} else {
+			ids = append(ids, b.Blocker)
+		}
+	}
+	return ids, nil
+}
+
+// isBlocked reports whether either user blocked the other
+func isBlocked(ctx context.Context, db *mongo.Database, a, b primitive.ObjectID) (bool, error) {
+	n, err := db.Collection("blocks").CountDocuments(ctx, bson.M{"$or": []bson.M{
+		{"blocker": a, "blocked": b},
+		{"blocker": b, "blocked": a},
+	}}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// BlockUserHandler handles POST /api/auth/blocks/{userId}. Blocking twice is fine. A match between
+// the two is closed for good, so the conversation stays shut if the block is lifted.
+func (h *Handler) BlockUserHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		otherID, err := primitive.ObjectIDFromHex(mux.Vars(r)["userId"])
+		if err != nil || otherID == objID {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now()
+		_, err = h.DB.Collection("blocks").UpdateOne(ctx,
+			bson.M{"blocker": objID, "blocked": otherID},
+			bson.M{"$setOnInsert": bson.M{"createdAt": now}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not block this user.",
+				err.Error(),
+			)
+			return
+		}
+
+		match := models.NewMatch(objID, otherID)
+		closeMatchesWhere(ctx, h.DB, h.WSManager, objID, bson.M{"user1": match.User1, "user2": match.User2}, now)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// UnblockUserHandler handles DELETE /api/auth/blocks/{userId}. Only the blocker can lift a block.
+func (h *Handler) UnblockUserHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		otherID, err := primitive.ObjectIDFromHex(mux.Vars(r)["userId"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if _, err := h.DB.Collection("blocks").DeleteOne(ctx, bson.M{"blocker": objID, "blocked": otherID}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not unblock this user.",
+				err.Error(),
+			)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListBlocksHandler handles GET /api/auth/blocks: the users the caller blocked, newest first
+func (h *Handler) ListBlocksHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := h.DB.Collection("blocks").Find(ctx, bson.M{"blocker": objID},
+			options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+		)
+		if err != nil {
+			http.Error(w, "Error loading blocks", http.StatusInternalServerError)
+			return
+		}
+		blocks := []models.Block{}
+		if err := cursor.All(ctx, &blocks); err != nil {
+			http.Error(w, "Decode error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(blocks)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ships-backend/internal/catalog"
+	"ships-backend/internal/validation"
+)
+
+// ProfileCatalogHandler handles GET /api/public/catalog/profile: every option the profile editor
+// and discovery filters offer
+func ProfileCatalogHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		json.NewEncoder(w).Encode(map[string]any{
+			"genders":           validation.Genders,
+			"education":         catalog.EducationLevels,
+			"relationshipGoals": catalog.RelationshipGoals,
+			"lifestyle": map[string][]string{
+				"drinking": catalog.DrinkingOptions,
+				"smoking":  catalog.SmokingOptions,
+				"kids":     catalog.KidsOptions,
+			},
+			"prompts":      catalog.Prompts,
+			"maxPrompts":   validation.MaxPrompts,
+			"visibilities": catalog.Visibilities,
+			"filters":      catalog.Filters,
+		})
+	}
+}
+
+// InterestCatalogHandler handles GET /api/public/catalog/interests. Names come in ?locale= or the
+// best match from Accept-Language; profiles store the IDs.
+func InterestCatalogHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locale := catalog.ResolveLocale(r.URL.Query().Get("locale"), r.Header.Get("Accept-Language"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Header().Set("Content-Language", locale)
+		w.Header().Add("Vary", "Accept-Language")
+		json.NewEncoder(w).Encode(map[string]any{
+			"locale":       locale,
+			"locales":      catalog.Locales,
+			"maxInterests": validation.MaxInterests,
+			"categories":   catalog.LocalizedInterests(locale),
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"ships-backend/internal/completeness"
+	"ships-backend/internal/middlewares"
+)
+
+type CompletenessResponse struct {
+	Score        int                 `json:"score"`
+	MinScore     int                 `json:"minScore"`
+	Discoverable bool                `json:"discoverable"` // whether the score is high enough to show up in discovery
+	Missing      []completeness.Step `json:"missing"`
+}
+
+// refreshCompleteness stores a new score after a change to one of its steps. A failure only
+// leaves the old score in place, so it is logged rather than failing the request.
+func refreshCompleteness(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) {
+	if _, err := completeness.Recompute(ctx, db, userID); err != nil {
+		log.Printf("completeness for user %s: %v", userID.Hex(), err)
+	}
+}
+
+// ProfileCompletenessHandler handles GET /api/auth/profile/completeness: the score and the
+// checklist of steps still missing
+func ProfileCompletenessHandler(db *mongo.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		res, err := completeness.Recompute(ctx, db, objID)
+		if err == mongo.ErrNoDocuments {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Could not check your profile", http.StatusInternalServerError)
+			return
+		}
+
+		minScore := completeness.MinScore()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(CompletenessResponse{
+			Score:        res.Score,
+			MinScore:     minScore,
+			Discoverable: res.Score >= minScore,
+			Missing:      res.Missing,
+		})
+	}
+}
@@ -42,7 +42,23 @@ func (h *Handler) GetCrossedPathsHandler() http.HandlerFunc {
 			}
 		}
 
-		// Filter by user and time
+		var me models.User
+		if err := h.DB.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&me); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		blocked, err := blockedIDs(ctx, h.DB, objID)
+		if err != nil {
+			http.Error(w, "Error loading crossed paths", http.StatusInternalServerError)
+			return
+		}
+		matched, err := matchedIDs(ctx, h.DB, objID)
+		if err != nil {
+			http.Error(w, "Error loading crossed paths", http.StatusInternalServerError)
+			return
+		}
+
+		// Filter by user and time, leaving out anyone blocked either way
 		filter := bson.M{
 			"$and": []bson.M{
 				{
@@ -54,6 +70,8 @@ func (h *Handler) GetCrossedPathsHandler() http.HandlerFunc {
 				{
 					"timestamp": bson.M{"$gte": time.Now().Add(-duration)},
 				},
+				{"user1": bson.M{"$nin": blocked}},
+				{"user2": bson.M{"$nin": blocked}},
 			},
 		}
 
@@ -72,7 +90,10 @@ func (h *Handler) GetCrossedPathsHandler() http.HandlerFunc {
 			return
 		}
 
+		now := time.Now()
 		userCol := h.DB.Collection("users")
+		var cards []models.PublicCard
+		cardIndex := map[int]int{} // crossed index -> cards index
 		for i := range crossed {
 			var otherID primitive.ObjectID
 			if crossed[i].User1 == objID {
@@ -82,12 +103,16 @@ func (h *Handler) GetCrossedPathsHandler() http.HandlerFunc {
 			}
 
 			var other models.User
-			err := userCol.FindOne(ctx, bson.M{"_id": otherID}).Decode(&other)
+			err := userCol.FindOne(ctx, visibleUsers(bson.M{"_id": otherID})).Decode(&other)
 			if err == nil {
-				other.Password = ""
-				crossed[i].OtherUser = &other
+				cardIndex[i] = len(cards)
+				cards = append(cards, publicCard(other, me, nil, matched[otherID], now))
 			}
 		}
+		cards = h.withPhotos(ctx, me.ID, cards)
+		for i, c := range cardIndex {
+			crossed[i].OtherUser = &cards[c]
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(crossed)
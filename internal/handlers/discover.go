@@ -3,17 +3,137 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 	"net/http"
+	"ships-backend/internal/catalog"
+	"ships-backend/internal/completeness"
 	"ships-backend/internal/middlewares"
 	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+	"ships-backend/internal/validation"
 	"strconv"
 	"strings"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	ErrCodeLocationRequired = "location_required"
+	maxPageSize             = 50
+)
+
+// discoveryPipeline finds the people `me` may be shown, nearest first, from their stored location
+// and preferences. It is reciprocal: a candidate only shows up when their own preferences
+// (gender, age range, distance, dealbreakers) also include `me`. Catalog filters only apply one way.
+// Profiles below the completeness threshold are left out. extra narrows the candidate set further, e.g. already seen users.
+func discoveryPipeline(me models.User, extra bson.M, now time.Time) mongo.Pipeline {
+	prefs := me.Preferences.WithDefaults()
+	myAge := validation.Age(me.Birth, now)
+
+	query := visibleUsers(extra)
+	if _, ok := query["_id"]; !ok {
+		query["_id"] = bson.M{"$ne": me.ID}
+	}
+	query["completeness"] = bson.M{"$gte": completeness.MinScore()}
+	// Candidate's age within my range
+	query["birth"] = bson.M{
+		"$lte": now.AddDate(-prefs.MinAge, 0, 0),
+		"$gt":  now.AddDate(-(prefs.MaxAge + 1), 0, 0),
+	}
+	if len(prefs.Genders) > 0 {
+		query["gender"] = bson.M{"$in": prefs.Genders}
+	}
+	if len(prefs.Dealbreakers) > 0 {
+		query["interests"] = bson.M{"$in": prefs.Dealbreakers}
+	}
+	// Fields someone hid can't be used to find them
+	for name, values := range prefs.Filters {
+		spec, ok := catalog.Filters[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		query[spec.Path] = bson.M{"$in": values}
+		query["visibility."+spec.Visibility] = bson.M{"$nin": bson.A{catalog.VisibleMatches, catalog.VisiblePrivate}}
+	}
+
+	myInterests := me.Interests
+	if myInterests == nil {
+		myInterests = []string{}
+	}
+
+	// Their side of the match, evaluated per candidate
+	reciprocal := bson.A{
+		// They want my gender, or didn't say
+		bson.M{"$or": bson.A{
+			bson.M{"$eq": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$preferences.genders", bson.A{}}}}, 0}},
+			bson.M{"$in": bson.A{me.Gender, bson.M{"$ifNull": bson.A{"$preferences.genders", bson.A{}}}}},
+		}},
+		// I'm inside their age range
+		bson.M{"$lte": bson.A{bson.M{"$ifNull": bson.A{"$preferences.minAge", models.DefaultMinAge}}, myAge}},
+		bson.M{"$gte": bson.A{bson.M{"$ifNull": bson.A{"$preferences.maxAge", models.DefaultMaxAge}}, myAge}},
+		// I'm inside their distance
+		bson.M{"$lte": bson.A{"$distance", bson.M{"$multiply": bson.A{
+			bson.M{"$ifNull": bson.A{"$preferences.maxDistanceKm", models.DefaultMaxDistanceKm}}, 1000,
+		}}}},
+		// I share one of their dealbreakers, if they have any
+		bson.M{"$or": bson.A{
+			bson.M{"$eq": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$preferences.dealbreakers", bson.A{}}}}, 0}},
+			bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$setIntersection": bson.A{
+				bson.M{"$ifNull": bson.A{"$preferences.dealbreakers", bson.A{}}}, myInterests,
+			}}}, 0}},
+		}},
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.M{
+			"near":          me.Location,
+			"key":           "location",
+			"distanceField": "distance",
+			"maxDistance":   prefs.MaxDistanceKm * 1000,
+			"spherical":     true,
+			"query":         query,
+		}}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": reciprocal}}}},
+	}
+}
+
+// loadDiscoveryUser loads the caller for discovery, answering the request itself when that isn't possible
+func (h *Handler) loadDiscoveryUser(ctx context.Context, w http.ResponseWriter, userID primitive.ObjectID) (models.User, bool) {
+	var me models.User
+	if err := h.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&me); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return me, false
+	}
+	if !me.HasLocation() {
+		utils.RespondWithErrorCode(w, http.StatusConflict, ErrCodeLocationRequired,
+			"Share your location to see people nearby",
+			"discovery without stored location for user "+me.ID.Hex(),
+		)
+		return me, false
+	}
+	return me, true
+}
+
+// pageParams reads limit and skip, defaulting to 10 and capping the page size
+func pageParams(r *http.Request) (limit, skip int) {
+	limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	skip, _ = strconv.Atoi(r.URL.Query().Get("skip"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	if skip < 0 {
+		skip = 0
+	}
+	return limit, skip
+}
+
+// NearbyUsersHandler handles GET /api/auth/nearby-users. Who shows up comes from the stored
+// location and preferences; ?interests=a,b only narrows it further.
 func (h *Handler) NearbyUsersHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -22,15 +142,11 @@ func (h *Handler) NearbyUsersHandler() http.HandlerFunc {
 		userID := r.Context().Value(middlewares.UserIDKey).(string)
 		currentUserID, _ := primitive.ObjectIDFromHex(userID)
 
-		// Parse query parameters
-		lat, _ := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
-		lng, _ := strconv.ParseFloat(r.URL.Query().Get("lng"), 64)
-		maxDistanceKm, _ := strconv.ParseFloat(r.URL.Query().Get("maxDistanceKm"), 64)
-		if maxDistanceKm == 0 {
-			maxDistanceKm = 5
+		me, ok := h.loadDiscoveryUser(ctx, w, currentUserID)
+		if !ok {
+			return
 		}
 
-		gender := r.URL.Query().Get("gender")
 		interestsQuery := r.URL.Query().Get("interests")
 		var interests []string
 		if interestsQuery != "" {
@@ -38,81 +154,56 @@ func (h *Handler) NearbyUsersHandler() http.HandlerFunc {
 		}
 
 		// 🧮 Paging
-		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
-		if limit == 0 {
-			limit = 10
-		}
+		limit, skip := pageParams(r)
 
-		// 🔍 Get already liked or seen users
+		// 🔍 Get already liked users
 		var liked []models.Like
 		likeCursor, _ := h.DB.Collection("likes").Find(ctx, bson.M{"fromUser": currentUserID})
 		_ = likeCursor.All(ctx, &liked)
 
-		seenIDs := map[string]bool{}
-		for _, like := range liked {
-			seenIDs[like.ToUser.Hex()] = true
+		blocked, err := blockedIDs(ctx, h.DB, currentUserID)
+		if err != nil {
+			http.Error(w, "Error querying nearby users", http.StatusInternalServerError)
+			return
 		}
 
-		// Build `$nin` array
-		var seenObjectIDs []primitive.ObjectID
-		for idStr := range seenIDs {
-			oid, err := primitive.ObjectIDFromHex(idStr)
-			if err == nil {
-				seenObjectIDs = append(seenObjectIDs, oid)
-			}
+		exclude := append([]primitive.ObjectID{currentUserID}, blocked...)
+		for _, like := range liked {
+			exclude = append(exclude, like.ToUser)
 		}
 
 		// 🔍 Build query
-		filter := bson.M{
-			"_id": bson.M{
-				"$ne":  currentUserID,
-				"$nin": seenObjectIDs,
-			},
-			"location": bson.M{
-				"$near": bson.M{
-					"$geometry": bson.M{
-						"type":        "Point",
-						"coordinates": []float64{lng, lat},
-					},
-					"$maxDistance": maxDistanceKm * 1000,
-				},
-			},
-		}
-
-		if gender != "" {
-			filter["gender"] = gender
-		}
-
+		extra := bson.M{"_id": bson.M{"$nin": exclude}}
 		if len(interests) > 0 {
-			filter["interests"] = bson.M{"$in": interests}
+			extra["$and"] = []bson.M{{"interests": bson.M{"$in": interests}}}
 		}
 
-		cursor, err := h.DB.Collection("users").Find(ctx, filter, &options.FindOptions{
-			Limit: int64Ptr(int64(limit)),
-			Skip:  int64Ptr(int64(skip)),
-		})
+		pipeline := append(discoveryPipeline(me, extra, time.Now()),
+			bson.D{{Key: "$skip", Value: skip}},
+			bson.D{{Key: "$limit", Value: limit}},
+		)
+
+		cursor, err := h.DB.Collection("users").Aggregate(ctx, pipeline)
 		if err != nil {
 			http.Error(w, "Error querying nearby users", http.StatusInternalServerError)
 			return
 		}
 		defer cursor.Close(ctx)
 
-		var users []models.User
-		if err := cursor.All(ctx, &users); err != nil {
+		var results []discoveryResult
+		if err := cursor.All(ctx, &results); err != nil {
 			http.Error(w, "Error decoding users", http.StatusInternalServerError)
 			return
 		}
 
-		for i := range users {
-			users[i].Password = ""
+		now := time.Now()
+		cards := make([]models.PublicCard, 0, len(results))
+		for _, res := range results {
+			cards = append(cards, publicCard(res.User, me, &res.Distance, false, now))
 		}
+		cards = h.withPhotos(ctx, me.ID, cards)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(users)
+		json.NewEncoder(w).Encode(cards)
 	}
 }
-
-func int64Ptr(v int64) *int64 {
-	return &v
-}
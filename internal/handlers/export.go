@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+)
+
+const exportDownloadTTL = 15 * time.Minute
+
+type ExportResponse struct {
+	models.DataExport
+	DownloadURL       string     `json:"downloadUrl,omitempty"`
+	DownloadExpiresAt *time.Time `json:"downloadExpiresAt,omitempty"`
+}
+
+// RequestExportHandler handles POST /api/auth/export and starts building the archive
+func (h *Handler) RequestExportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		export, err := h.Exports.Start(ctx, objID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not start the export.",
+				err.Error(),
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(ExportResponse{DataExport: export})
+	}
+}
+
+// ExportStatusHandler handles GET /api/auth/export/{exportId}. Once the archive is ready every
+// call hands out a fresh download link that works for a few minutes without the JWT.
+func (h *Handler) ExportStatusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		exportID, err := primitive.ObjectIDFromHex(mux.Vars(r)["exportId"])
+		if err != nil {
+			http.Error(w, "Invalid export ID", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now()
+		exports := h.DB.Collection("exports")
+
+		var export models.DataExport
+		err = exports.FindOne(ctx, bson.M{
+			"_id":       exportID,
+			"userId":    objID,
+			"expiresAt": bson.M{"$gt": now},
+		}).Decode(&export)
+		if err != nil {
+			http.Error(w, "Export not found", http.StatusNotFound)
+			return
+		}
+
+		resp := ExportResponse{DataExport: export}
+		if export.Status == models.ExportReady {
+			token := utils.GenerateRandomToken(32)
+			expires := now.Add(exportDownloadTTL)
+			if expires.After(export.ExpiresAt) {
+				expires = export.ExpiresAt
+			}
+
+			err = exports.FindOneAndUpdate(ctx,
+				bson.M{"_id": export.ID},
+				bson.M{"$set": bson.M{"downloadTokenHash": utils.HashToken(token), "downloadExpiresAt": expires}},
+				options.FindOneAndUpdate().SetReturnDocument(options.After),
+			).Decode(&resp.DataExport)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError,
+					"Could not create a download link.",
+					err.Error(),
+				)
+				return
+			}
+
+			resp.DownloadURL = h.Mail.Link("/api/public/export/download", url.Values{"token": {token}})
+			resp.DownloadExpiresAt = &expires
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// DownloadExportHandler handles GET /api/public/export/download?token=... and streams the archive
+func (h *Handler) DownloadExportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			http.Error(w, "Invalid or expired link", http.StatusNotFound)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now()
+
+		var export models.DataExport
+		err := h.DB.Collection("exports").FindOne(ctx, bson.M{
+			"downloadTokenHash": utils.HashToken(token),
+			"downloadExpiresAt": bson.M{"$gt": now},
+			"status":            models.ExportReady,
+		}).Decode(&export)
+		if err != nil {
+			http.Error(w, "Invalid or expired link", http.StatusNotFound)
+			return
+		}
+
+		// Deleted accounts keep their archive only until the purge, and nobody may fetch it meanwhile
+		count, err := h.DB.Collection("users").CountDocuments(ctx, bson.M{"_id": export.UserID, "deletedAt": bson.M{"$exists": false}})
+		if err != nil || count == 0 {
+			http.Error(w, "Invalid or expired link", http.StatusNotFound)
+			return
+		}
+
+		f, err := os.Open(export.File)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not read the export.",
+				err.Error(),
+			)
+			return
+		}
+		defer f.Close()
+
+		name := "ships-data-" + export.CreatedAt.Format("2006-01-02") + ".zip"
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		w.Header().Set("Cache-Control", "no-store")
+		modTime := export.CreatedAt
+		if export.CompletedAt != nil {
+			modTime = *export.CompletedAt
+		}
+		http.ServeContent(w, r, name, modTime, f)
+	}
+}
@@ -2,17 +2,32 @@ package handlers
 
 import (
 	"go.mongodb.org/mongo-driver/mongo"
+	"ships-backend/internal/blob"
+	"ships-backend/internal/export"
+	"ships-backend/internal/mail"
+	"ships-backend/internal/moderation"
+	"ships-backend/internal/photourl"
 	"ships-backend/internal/ws"
 )
 
 type Handler struct {
-	DB        *mongo.Database
-	WSManager *ws.Manager
+	DB         *mongo.Database
+	WSManager  *ws.Manager
+	Mail       *mail.Service
+	Exports    *export.Service
+	Blobs      blob.Store
+	PhotoURLs  *photourl.Signer
+	Moderation *moderation.Service
 }
 
-func NewHandler(db *mongo.Database, wsManager *ws.Manager) *Handler {
+func NewHandler(db *mongo.Database, wsManager *ws.Manager, mailService *mail.Service, exports *export.Service, blobs blob.Store, photoURLs *photourl.Signer, moderationService *moderation.Service) *Handler {
 	return &Handler{
-		DB:        db,
-		WSManager: wsManager,
+		DB:         db,
+		WSManager:  wsManager,
+		Mail:       mailService,
+		Exports:    exports,
+		Blobs:      blobs,
+		PhotoURLs:  photoURLs,
+		Moderation: moderationService,
 	}
 }
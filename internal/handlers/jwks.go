@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ships-backend/internal/utils"
+)
+
+// JWKSHandler handles GET /.well-known/jwks.json so other services can verify our access tokens
+func JWKSHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		json.NewEncoder(w).Encode(utils.PublicJWKS())
+	}
+}
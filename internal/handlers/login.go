@@ -4,13 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 
+	"ships-backend/internal/middlewares"
 	"ships-backend/internal/models"
+	"ships-backend/internal/ratelimit"
 	"ships-backend/internal/utils"
 )
 
@@ -27,8 +32,44 @@ type LoginResponse struct {
 	RefreshToken string      `json:"refreshToken"`
 }
 
-// LoginHandler handles POST /api/login
-func LoginHandler(db *mongo.Database) http.HandlerFunc {
+// Failed logins are counted per account and per client IP
+var (
+	accountLoginPolicy = ratelimit.Policy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 10,
+		LockoutFor:   15 * time.Minute,
+		Window:       15 * time.Minute,
+	}
+	ipLoginPolicy = ratelimit.Policy{
+		FreeAttempts: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 50,
+		LockoutFor:   15 * time.Minute,
+		Window:       15 * time.Minute,
+	}
+)
+
+const (
+	ErrCodeAccountLocked   = "account_locked"
+	ErrCodeTooManyAttempts = "too_many_attempts"
+	ErrCodeAccountDeleted  = "account_deleted"
+)
+
+func respondThrottled(w http.ResponseWriter, decision ratelimit.Decision, code, message, logMessage string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(decision.RetryAfter.Seconds())+1))
+
+	status := http.StatusTooManyRequests
+	if code == ErrCodeAccountLocked {
+		status = http.StatusLocked
+	}
+	utils.RespondWithErrorCode(w, status, code, message, logMessage)
+}
+
+// LoginHandler handles POST /api/public/auth/login
+func (h *AuthHandler) LoginHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req LoginRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -36,74 +77,107 @@ func LoginHandler(db *mongo.Database) http.HandlerFunc {
 			return
 		}
 
+		accountKey := "account:" + strings.ToLower(strings.TrimSpace(req.Email))
+		ipKey := "ip:" + utils.GetClientIP(r)
+
+		if decision := h.accountGuard.Check(accountKey); decision.Locked {
+			respondThrottled(w, decision, ErrCodeAccountLocked,
+				"This account is temporarily locked after too many failed logins",
+				"login blocked, account locked: "+accountKey,
+			)
+			return
+		} else if !decision.Allowed {
+			respondThrottled(w, decision, ErrCodeTooManyAttempts,
+				"Too many failed logins, try again later",
+				"login backoff for "+accountKey,
+			)
+			return
+		}
+
+		if decision := h.ipGuard.Check(ipKey); !decision.Allowed {
+			respondThrottled(w, decision, ErrCodeTooManyAttempts,
+				"Too many failed logins, try again later",
+				"login backoff for "+ipKey,
+			)
+			return
+		}
+
 		var user models.User
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		users := db.Collection("users")
+		users := h.db.Collection("users")
 		err := users.FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
-		if err != nil {
-			utils.RespondWithError(w, http.StatusUnauthorized,
-				"Invalid email or password",
-				err.Error(),
-			)
-			return
+		if err == nil {
+			err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 		}
-
-		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		if err != nil {
+			// Unknown emails count too, so the counters don't reveal which accounts exist
+			h.ipGuard.Fail(ipKey)
+			attempts, lockedNow := h.accountGuard.Fail(accountKey)
+			if lockedNow && !user.ID.IsZero() {
+				h.sendLockoutEmail(user, attempts.LockedUntil)
+			}
 
 			utils.RespondWithError(w, http.StatusUnauthorized,
 				"Invalid email or password",
 				err.Error(),
 			)
-
 			return
 		}
 
-		token, err := utils.GenerateJWT(user.ID.Hex())
-		if err != nil {
+		h.accountGuard.Succeed(accountKey)
 
-			utils.RespondWithError(w, http.StatusInternalServerError,
-				"Error generating token",
-				err.Error(),
-			)
+		h.completeLogin(ctx, w, r, user)
+	}
+}
 
-			return
-		}
+// respondWithLogin signs the user in on this device and writes the LoginResponse
+func (h *AuthHandler) respondWithLogin(ctx context.Context, w http.ResponseWriter, r *http.Request, user models.User) {
+	token, refreshToken, err := startSession(ctx, h.db, user.ID, r)
+	if err != nil {
 
-		refreshToken := utils.GenerateRandomToken(64)
-		// Store refreshToken in DB (optional but recommended)
-		_, err = db.Collection("refresh_tokens").InsertOne(context.TODO(), bson.M{
-			"userId":    user.ID,
-			"token":     refreshToken,
-			"createdAt": time.Now(),
-		})
+		utils.RespondWithError(w, http.StatusInternalServerError,
+			"Error generating token",
+			err.Error(),
+		)
 
-		user.Password = "" // Hide password
+		return
+	}
 
-		response := LoginResponse{
-			Token:        token,
-			User:         user,
-			RefreshToken: refreshToken,
-		}
+	user.Password = "" // Hide password
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+	response := LoginResponse{
+		Token:        token,
+		User:         user,
+		RefreshToken: refreshToken,
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AuthHandler) sendLockoutEmail(user models.User, until time.Time) {
+	h.mail.SendAsync(user.Email, "account_locked", map[string]any{
+		"Name":      user.Name,
+		"Until":     until.UTC().Format("15:04 MST"),
+		"ResetLink": h.mail.Link("/api/public/auth/password/reset", nil),
+	})
 }
 
+// LogoutHandler signs out the device that made the request
 func LogoutHandler(db *mongo.Database) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Context().Value("userID") // from your auth middleware
-		if userID == nil {
-			utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", "missing user ID in context")
-			return
-		}
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		sessionID := r.Context().Value(middlewares.SessionIDKey).(string)
+		userObjID, _ := primitive.ObjectIDFromHex(userID)
+		sessionObjID, _ := primitive.ObjectIDFromHex(sessionID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
 
-		// Optionally read refresh token from header/body if needed
-		// For now, we’ll remove all user refresh tokens
-		_, err := db.Collection("refresh_tokens").DeleteMany(context.TODO(), bson.M{"userId": userID})
+		_, err := revokeSession(ctx, db, userObjID, sessionObjID)
 		if err != nil {
 			utils.RespondWithError(w, http.StatusInternalServerError,
 				"Could not logout.",
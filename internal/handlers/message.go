@@ -38,6 +38,10 @@ func (h *Handler) SendMessageHandler() http.HandlerFunc {
 			http.Error(w, "Not authorized to message in this match", http.StatusForbidden)
 			return
 		}
+		if match.ClosedAt != nil {
+			http.Error(w, "This conversation has been closed", http.StatusGone)
+			return
+		}
 
 		var req struct {
 			Content string `json:"content"`
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/moderation"
+	"ships-backend/internal/utils"
+	"ships-backend/internal/validation"
+)
+
+// maxModerationNote bounds the moderator's note to the owner
+const maxModerationNote = 500
+
+// ModerationItem is one photo in the moderation queue
+type ModerationItem struct {
+	Photo        models.PhotoRef    `json:"photo"` // URLs signed for the moderator
+	UserID       primitive.ObjectID `json:"userId"`
+	UploadedAt   time.Time          `json:"uploadedAt"`
+	Labels       []string           `json:"labels"` // what the classifier noticed
+	OwnerStrikes int                `json:"ownerStrikes"`
+	OwnerFlagged bool               `json:"ownerFlagged"`
+}
+
+// FlaggedUser is an account that reached the photo strike limit
+type FlaggedUser struct {
+	ID           primitive.ObjectID `json:"id"`
+	Name         string             `json:"name"`
+	Email        string             `json:"email"`
+	PhotoStrikes int                `json:"photoStrikes"`
+	FlaggedAt    *time.Time         `json:"flaggedAt"`
+}
+
+type rejectPhotoRequest struct {
+	Reason string `json:"reason"`
+	Note   string `json:"note"`
+}
+
+// ModerationQueueHandler handles GET /api/admin/moderation/photos?limit=&skip=, oldest first
+func (h *Handler) ModerationQueueHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		adminID, _ := primitive.ObjectIDFromHex(userID)
+		limit, skip := pageParams(r)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		photos, err := h.Moderation.Queue(ctx, limit, skip)
+		if err != nil {
+			http.Error(w, "Error loading queue", http.StatusInternalServerError)
+			return
+		}
+
+		ownerIDs := make([]primitive.ObjectID, 0, len(photos))
+		for _, p := range photos {
+			ownerIDs = append(ownerIDs, p.UserID)
+		}
+		var owners []models.User
+		cursor, err := h.DB.Collection("users").Find(ctx, bson.M{"_id": bson.M{"$in": ownerIDs}})
+		if err == nil {
+			err = cursor.All(ctx, &owners)
+		}
+		if err != nil {
+			http.Error(w, "Error loading queue", http.StatusInternalServerError)
+			return
+		}
+		ownerByID := make(map[primitive.ObjectID]models.User, len(owners))
+		for _, u := range owners {
+			ownerByID[u.ID] = u
+		}
+
+		now := time.Now()
+		items := make([]ModerationItem, 0, len(photos))
+		for _, p := range photos {
+			owner := ownerByID[p.UserID]
+			item := ModerationItem{
+				Photo:        h.photoRef(p, adminID, now),
+				UserID:       p.UserID,
+				UploadedAt:   p.CreatedAt,
+				Labels:       p.Moderation.Labels,
+				OwnerStrikes: owner.PhotoStrikes,
+				OwnerFlagged: owner.FlaggedAt != nil,
+			}
+			if item.Labels == nil {
+				item.Labels = []string{}
+			}
+			items = append(items, item)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(items)
+	}
+}
+
+// ModerationReasonsHandler handles GET /api/admin/moderation/reasons, the choices for a rejection
+func (h *Handler) ModerationReasonsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(moderation.Reasons)
+	}
+}
+
+// ApprovePhotoHandler handles POST /api/admin/moderation/photos/{photoId}/approve
+func (h *Handler) ApprovePhotoHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		adminID, _ := primitive.ObjectIDFromHex(userID)
+
+		photoID, err := primitive.ObjectIDFromHex(mux.Vars(r)["photoId"])
+		if err != nil {
+			http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, err = h.Moderation.Approve(ctx, photoID, adminID)
+		if respondModerationError(w, err) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RejectPhotoHandler handles POST /api/admin/moderation/photos/{photoId}/reject with
+// { reason, note }. Live photos can be rejected too, e.g. after a report.
+func (h *Handler) RejectPhotoHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		adminID, _ := primitive.ObjectIDFromHex(userID)
+
+		photoID, err := primitive.ObjectIDFromHex(mux.Vars(r)["photoId"])
+		if err != nil {
+			http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+			return
+		}
+
+		var req rejectPhotoRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		req.Note = strings.TrimSpace(req.Note)
+
+		v := validation.New()
+		v.Enum("reason", req.Reason, moderation.ReasonIDs())
+		v.MaxLength("note", req.Note, maxModerationNote)
+		if !v.Valid() {
+			utils.RespondWithFieldErrors(w, v.Errors)
+			return
+		}
+		reason, _ := moderation.ReasonByID(req.Reason)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, err = h.Moderation.Reject(ctx, photoID, adminID, reason, req.Note)
+		if respondModerationError(w, err) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// FlaggedUsersHandler handles GET /api/admin/moderation/flagged-users
+func (h *Handler) FlaggedUsersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		users, err := h.Moderation.Flagged(ctx)
+		if err != nil {
+			http.Error(w, "Error loading users", http.StatusInternalServerError)
+			return
+		}
+
+		flagged := make([]FlaggedUser, 0, len(users))
+		for _, u := range users {
+			flagged = append(flagged, FlaggedUser{
+				ID:           u.ID,
+				Name:         u.Name,
+				Email:        u.Email,
+				PhotoStrikes: u.PhotoStrikes,
+				FlaggedAt:    u.FlaggedAt,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(flagged)
+	}
+}
+
+// respondModerationError writes the response for a failed decision and reports whether there was one
+func respondModerationError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, moderation.ErrNotFound):
+		http.Error(w, "Photo not found", http.StatusNotFound)
+	case errors.Is(err, moderation.ErrNotPending), errors.Is(err, moderation.ErrRejected):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Could not update photo", http.StatusInternalServerError)
+	}
+	return true
+}
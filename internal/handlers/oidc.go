@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/completeness"
+	"ships-backend/internal/models"
+	"ships-backend/internal/oidc"
+	"ships-backend/internal/utils"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+// emailCollation makes email lookups case-insensitive; IdPs don't always preserve the case users registered with
+var emailCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// OIDCStartHandler handles GET /api/public/auth/oidc/{provider}/start and returns the URL to open in the browser
+func (h *AuthHandler) OIDCStartHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["provider"]
+		provider, ok := h.oidcProviders[name]
+		if !ok {
+			http.Error(w, "Unknown provider", http.StatusNotFound)
+			return
+		}
+
+		state, err := oidc.RandomString()
+		nonce, err2 := oidc.RandomString()
+		verifier, challenge, err3 := oidc.NewPKCE()
+		if err != nil || err2 != nil || err3 != nil {
+			http.Error(w, "Could not start login", http.StatusInternalServerError)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now()
+		_, err = h.db.Collection("oidc_states").InsertOne(ctx, models.OIDCState{
+			Provider:     name,
+			StateHash:    utils.HashToken(state),
+			Nonce:        nonce,
+			CodeVerifier: verifier,
+			CreatedAt:    now,
+			ExpiresAt:    now.Add(oidcStateTTL),
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not start login",
+				err.Error(),
+			)
+			return
+		}
+
+		authURL, err := provider.AuthCodeURL(ctx, state, nonce, challenge)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadGateway,
+				"Login provider unavailable",
+				err.Error(),
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"authorizationUrl": authURL})
+	}
+}
+
+// OIDCCallbackHandler handles the provider redirect at /api/public/auth/oidc/{provider}/callback.
+// Browsers arrive with ?code=&state= (GET); native apps that catch the redirect themselves POST { code, state }.
+func (h *AuthHandler) OIDCCallbackHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["provider"]
+		provider, ok := h.oidcProviders[name]
+		if !ok {
+			http.Error(w, "Unknown provider", http.StatusNotFound)
+			return
+		}
+
+		req := OIDCCallbackRequest{
+			Code:  r.URL.Query().Get("code"),
+			State: r.URL.Query().Get("state"),
+		}
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+		if errCode := r.URL.Query().Get("error"); errCode != "" {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Login was cancelled or denied",
+				"oidc provider returned "+errCode,
+			)
+			return
+		}
+		if req.Code == "" || req.State == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		// The state is single-use
+		var state models.OIDCState
+		err := h.db.Collection("oidc_states").FindOneAndDelete(ctx, bson.M{
+			"stateHash": utils.HashToken(req.State),
+			"provider":  name,
+			"expiresAt": bson.M{"$gt": time.Now()},
+		}).Decode(&state)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Login expired, please try again",
+				err.Error(),
+			)
+			return
+		}
+
+		claims, err := provider.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Could not sign in with "+name,
+				err.Error(),
+			)
+			return
+		}
+
+		user, err := h.findOrCreateOIDCUser(ctx, name, claims)
+		if err == errEmailTaken {
+			utils.RespondWithErrorCode(w, http.StatusConflict, "email_in_use",
+				"An account with this email already exists. Sign in with your password first.",
+				"oidc login with unverified email matching an existing account",
+			)
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not sign in with "+name,
+				err.Error(),
+			)
+			return
+		}
+
+		h.completeLogin(ctx, w, r, user)
+	}
+}
+
+var errEmailTaken = errors.New("email already registered to another account")
+
+// findOrCreateOIDCUser resolves the external identity to a local user: an existing link first,
+// then an account with the same verified email, otherwise a new password-less user
+func (h *AuthHandler) findOrCreateOIDCUser(ctx context.Context, provider string, claims *oidc.IDClaims) (models.User, error) {
+	users := h.db.Collection("users")
+	now := time.Now()
+
+	var user models.User
+	err := users.FindOne(ctx, bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": claims.Subject}},
+	}).Decode(&user)
+	if err == nil {
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return user, err
+	}
+
+	identity := models.ExternalIdentity{Provider: provider, Subject: claims.Subject, LinkedAt: now}
+
+	var existing models.User
+	err = mongo.ErrNoDocuments
+	if claims.Email != "" {
+		err = users.FindOne(ctx, bson.M{"email": claims.Email}, options.FindOne().SetCollation(emailCollation)).Decode(&existing)
+	}
+	switch {
+	case err == nil && claims.EmailVerified:
+		update := bson.M{
+			"$push": bson.M{"identities": identity},
+			"$set":  bson.M{"emailVerified": true, "updatedAt": now},
+		}
+		if !existing.EmailVerified {
+			// Nobody proved they own this address before, so whoever set the password may not be the
+			// owner. Drop the password and their sessions rather than hand them a linked account.
+			update["$unset"] = bson.M{"password": "", "verifyToken": "", "verifyTokenExpiresAt": ""}
+			if err := revokeAllSessions(ctx, h.db, existing.ID); err != nil {
+				return user, err
+			}
+		}
+
+		err = users.FindOneAndUpdate(ctx, bson.M{"_id": existing.ID}, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&user)
+		if err == nil && !existing.EmailVerified {
+			refreshCompleteness(ctx, h.db, user.ID)
+		}
+		return user, err
+
+	case err == nil:
+		return user, errEmailTaken
+
+	case err != mongo.ErrNoDocuments:
+		return user, err
+	}
+
+	user = models.User{
+		ID:            primitive.NewObjectID(),
+		Name:          claims.Name,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Identities:    []models.ExternalIdentity{identity},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Location: models.Location{
+			Type:        "Point",
+			Coordinates: []float64{0.0, 0.0}, // default empty location
+		},
+	}
+	user.Completeness = completeness.Score(user, 0).Score
+	if _, err := users.InsertOne(ctx, user); err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
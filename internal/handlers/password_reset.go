@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/crypto/bcrypt"
+
+	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+	"ships-backend/internal/validation"
+)
+
+const passwordResetTTL = time.Hour
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ForgotPasswordHandler handles POST /api/public/auth/password/forgot.
+// It always answers the same way so it can't be used to probe which emails are registered.
+func (h *AuthHandler) ForgotPasswordHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ForgotPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		respond := func() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": "If that email is registered, a reset link has been sent.",
+			})
+		}
+
+		var user models.User
+		err := h.db.Collection("users").FindOne(ctx, bson.M{
+			"email":     strings.TrimSpace(req.Email),
+			"deletedAt": bson.M{"$exists": false},
+		}).Decode(&user)
+		if err != nil {
+			respond()
+			return
+		}
+
+		resets := h.db.Collection("password_resets")
+		now := time.Now()
+
+		// Only the most recent link works
+		_, _ = resets.UpdateMany(ctx,
+			bson.M{"userId": user.ID, "usedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"usedAt": now}},
+		)
+
+		token := utils.GenerateRandomToken(32)
+		_, err = resets.InsertOne(ctx, models.PasswordReset{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(token),
+			IP:        utils.GetClientIP(r),
+			CreatedAt: now,
+			ExpiresAt: now.Add(passwordResetTTL),
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not start password reset.",
+				err.Error(),
+			)
+			return
+		}
+
+		respond()
+
+		h.sendPasswordResetEmail(user.Email, token)
+	}
+}
+
+// ResetPasswordLinkHandler handles GET /api/public/auth/password/reset?token=..., the link in the
+// reset email. It opens the app's reset screen (APP_RESET_PASSWORD_URL) with the token, and the
+// app then POSTs the new password. Without a token (the lockout email) the app asks for an email
+// and starts over with /password/forgot.
+func (h *AuthHandler) ResetPasswordLinkHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := url.Values{}
+		if token := r.URL.Query().Get("token"); token != "" {
+			params.Set("token", token)
+		}
+		appRedirect(w, r, "APP_RESET_PASSWORD_URL", "ships://reset-password", params)
+	}
+}
+
+// ResetPasswordHandler handles POST /api/public/auth/password/reset
+func (h *AuthHandler) ResetPasswordHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ResetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		v := validation.New()
+		v.Password("password", req.Password)
+		if !v.Valid() {
+			utils.RespondWithFieldErrors(w, v.Errors)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now()
+
+		// Consume the token atomically so it can only ever be used once
+		var reset models.PasswordReset
+		err := h.db.Collection("password_resets").FindOneAndUpdate(ctx,
+			bson.M{
+				"tokenHash": utils.HashToken(req.Token),
+				"usedAt":    bson.M{"$exists": false},
+				"expiresAt": bson.M{"$gt": now},
+			},
+			bson.M{"$set": bson.M{"usedAt": now}},
+		).Decode(&reset)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest,
+				"Invalid or expired token",
+				err.Error(),
+			)
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), 10)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not reset password.",
+				err.Error(),
+			)
+			return
+		}
+
+		_, err = h.db.Collection("users").UpdateByID(ctx, reset.UserID, bson.M{
+			"$set": bson.M{
+				"password":  string(hashedPassword),
+				"updatedAt": now,
+			},
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not reset password.",
+				err.Error(),
+			)
+			return
+		}
+
+		// Whoever had the old password is signed out everywhere
+		if err := revokeAllSessions(ctx, h.db, reset.UserID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Password changed but sessions could not be revoked.",
+				err.Error(),
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Password updated. Please log in again."})
+	}
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/models"
+	"ships-backend/internal/ratelimit"
+	"ships-backend/internal/utils"
+)
+
+const (
+	loginCodeTTL         = 10 * time.Minute
+	loginCodeMaxAttempts = 5
+)
+
+// Each code request counts as an "attempt": a few are free, then they back off per email
+var loginCodeSendPolicy = ratelimit.Policy{
+	FreeAttempts: 3,
+	BaseDelay:    30 * time.Second,
+	MaxDelay:     10 * time.Minute,
+	Window:       time.Hour,
+}
+
+type PasswordlessStartRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordlessVerifyRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+	Token string `json:"token"`
+}
+
+func generateLoginCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// PasswordlessStartHandler handles POST /api/public/auth/passwordless/start.
+// Like ForgotPasswordHandler it answers the same way whether or not the email is registered.
+func (h *AuthHandler) PasswordlessStartHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PasswordlessStartRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		email := strings.TrimSpace(req.Email)
+
+		key := "login-code:" + strings.ToLower(email)
+		if decision := h.loginCodeGuard.Check(key); !decision.Allowed {
+			respondThrottled(w, decision, ErrCodeTooManyAttempts,
+				"Please wait before requesting another code",
+				"login code throttled for "+key,
+			)
+			return
+		}
+		h.loginCodeGuard.Fail(key)
+
+		respond := func() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": "If that email is registered, a sign-in code has been sent.",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := h.db.Collection("users").FindOne(ctx, bson.M{"email": email, "deletedAt": bson.M{"$exists": false}}).Decode(&user); err != nil {
+			respond()
+			return
+		}
+
+		code, err := generateLoginCode()
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not send a sign-in code.",
+				err.Error(),
+			)
+			return
+		}
+		linkToken := utils.GenerateRandomToken(32)
+
+		codes := h.db.Collection("login_codes")
+		now := time.Now()
+
+		// Only the newest code works
+		_, _ = codes.UpdateMany(ctx,
+			bson.M{"userId": user.ID, "usedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"usedAt": now}},
+		)
+
+		_, err = codes.InsertOne(ctx, models.LoginCode{
+			UserID:        user.ID,
+			Email:         user.Email,
+			CodeHash:      utils.HashToken(code),
+			LinkTokenHash: utils.HashToken(linkToken),
+			IP:            utils.GetClientIP(r),
+			CreatedAt:     now,
+			ExpiresAt:     now.Add(loginCodeTTL),
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not send a sign-in code.",
+				err.Error(),
+			)
+			return
+		}
+
+		respond()
+
+		h.mail.SendAsync(user.Email, "login_code", map[string]any{
+			"Code":    code,
+			"Link":    h.mail.Link("/api/public/auth/passwordless/verify", url.Values{"token": {linkToken}}),
+			"Minutes": int(loginCodeTTL.Minutes()),
+		})
+	}
+}
+
+// MagicLinkHandler handles GET /api/public/auth/passwordless/verify?token=..., the emailed link.
+// It opens the app (APP_MAGIC_LINK_URL) with the token, which the app POSTs back here to sign in;
+// signing in from a GET would let link scanners in mail clients use up the token.
+func (h *AuthHandler) MagicLinkHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		appRedirect(w, r, "APP_MAGIC_LINK_URL", "ships://magic-login", url.Values{"token": {r.URL.Query().Get("token")}})
+	}
+}
+
+// PasswordlessVerifyHandler handles POST /api/public/auth/passwordless/verify with either
+// { email, code } or the magic link { token }. It responds exactly like LoginHandler.
+func (h *AuthHandler) PasswordlessVerifyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PasswordlessVerifyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		codes := h.db.Collection("login_codes")
+		now := time.Now()
+		active := bson.M{"usedAt": bson.M{"$exists": false}, "expiresAt": bson.M{"$gt": now}}
+
+		var loginCode models.LoginCode
+		switch {
+		case req.Token != "":
+			filter := bson.M{"linkTokenHash": utils.HashToken(req.Token)}
+			for k, v := range active {
+				filter[k] = v
+			}
+			if err := codes.FindOne(ctx, filter).Decode(&loginCode); err != nil {
+				utils.RespondWithError(w, http.StatusUnauthorized,
+					"Invalid or expired code",
+					err.Error(),
+				)
+				return
+			}
+
+		case req.Email != "" && req.Code != "":
+			filter := bson.M{"email": strings.TrimSpace(req.Email)}
+			for k, v := range active {
+				filter[k] = v
+			}
+			err := codes.FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})).Decode(&loginCode)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusUnauthorized,
+					"Invalid or expired code",
+					err.Error(),
+				)
+				return
+			}
+
+			// A 6-digit code only survives a handful of guesses. Each guess takes an attempt before
+			// it is checked, in one conditional update, so parallel guesses can't exceed the limit.
+			err = codes.FindOneAndUpdate(ctx,
+				bson.M{
+					"_id":      loginCode.ID,
+					"usedAt":   bson.M{"$exists": false},
+					"attempts": bson.M{"$lt": loginCodeMaxAttempts},
+				},
+				bson.M{"$inc": bson.M{"attempts": 1}},
+				options.FindOneAndUpdate().SetReturnDocument(options.After),
+			).Decode(&loginCode)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusUnauthorized,
+					"Invalid or expired code",
+					"no attempts left on login code: "+err.Error(),
+				)
+				return
+			}
+
+			if subtle.ConstantTimeCompare([]byte(utils.HashToken(strings.TrimSpace(req.Code))), []byte(loginCode.CodeHash)) != 1 {
+				if loginCode.Attempts >= loginCodeMaxAttempts {
+					_, _ = codes.UpdateOne(ctx,
+						bson.M{"_id": loginCode.ID, "usedAt": bson.M{"$exists": false}},
+						bson.M{"$set": bson.M{"usedAt": now}},
+					)
+				}
+
+				utils.RespondWithError(w, http.StatusUnauthorized,
+					"Invalid or expired code",
+					"wrong login code for "+loginCode.Email,
+				)
+				return
+			}
+
+		default:
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		// Consume it atomically so it only works once
+		res, err := codes.UpdateOne(ctx,
+			bson.M{"_id": loginCode.ID, "usedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"usedAt": now}},
+		)
+		if err != nil || res.ModifiedCount == 0 {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Invalid or expired code",
+				"login code already used for "+loginCode.Email,
+			)
+			return
+		}
+
+		// Receiving the code proves the user owns the address
+		var user models.User
+		err = h.db.Collection("users").FindOneAndUpdate(ctx,
+			bson.M{"_id": loginCode.UserID, "deletedAt": bson.M{"$exists": false}},
+			bson.M{
+				"$set":   bson.M{"emailVerified": true},
+				"$unset": bson.M{"verifyToken": "", "verifyTokenExpiresAt": ""},
+			},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&user)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Invalid or expired code",
+				err.Error(),
+			)
+			return
+		}
+
+		refreshCompleteness(ctx, h.db, user.ID)
+		h.accountGuard.Succeed("account:" + strings.ToLower(user.Email))
+
+		h.completeLogin(ctx, w, r, user)
+	}
+}
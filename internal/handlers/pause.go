@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+)
+
+const maxPauseDuration = 365 * 24 * time.Hour
+
+type PauseRequest struct {
+	Until *time.Time `json:"until"` // optional auto-resume time
+}
+
+type PauseResponse struct {
+	Paused      bool       `json:"paused"`
+	PausedAt    *time.Time `json:"pausedAt,omitempty"`
+	PausedUntil *time.Time `json:"pausedUntil,omitempty"`
+}
+
+// PauseHandler handles POST /api/auth/pause. Pausing again replaces the resume date.
+func (h *Handler) PauseHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		var req PauseRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+
+		now := time.Now()
+		if req.Until != nil && (!req.Until.After(now) || req.Until.Sub(now) > maxPauseDuration) {
+			http.Error(w, "until must be in the future and within a year", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var current models.User
+		if err := h.DB.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&current); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		// Keep the original start when only the resume date changes
+		pausedAt := now
+		if current.IsPaused(now) {
+			pausedAt = *current.PausedAt
+		}
+
+		// Not a profile edit, so updatedAt (the profile ETag) stays as it is
+		update := bson.M{"$set": bson.M{"pausedAt": pausedAt}}
+		if req.Until != nil {
+			update["$set"].(bson.M)["pausedUntil"] = *req.Until
+		} else {
+			update["$unset"] = bson.M{"pausedUntil": ""}
+		}
+
+		var user models.User
+		err := h.DB.Collection("users").FindOneAndUpdate(ctx, bson.M{"_id": objID}, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&user)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not pause your account.",
+				err.Error(),
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(PauseResponse{
+			Paused:      true,
+			PausedAt:    user.PausedAt,
+			PausedUntil: user.PausedUntil,
+		})
+	}
+}
+
+// ResumeHandler handles POST /api/auth/resume
+func (h *Handler) ResumeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, err := h.DB.Collection("users").UpdateByID(ctx, objID, bson.M{
+			"$unset": bson.M{"pausedAt": "", "pausedUntil": ""},
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not resume your account.",
+				err.Error(),
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(PauseResponse{Paused: false})
+	}
+}
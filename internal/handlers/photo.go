@@ -3,26 +3,49 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"io"
+	"log"
 	"net/http"
+	"strings"
 	"ships-backend/internal/middlewares"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
+	"ships-backend/internal/blob"
+	"ships-backend/internal/imaging"
 	"ships-backend/internal/models"
+	"ships-backend/internal/moderation"
+	"ships-backend/internal/utils"
+	"ships-backend/internal/validation"
 )
 
+// maxPhotoUploadBytes bounds the multipart body of an upload
+const maxPhotoUploadBytes = 15 << 20
+
+// deleteBlobs removes every rendition of a photo. Failures only leave unreachable bytes behind,
+// so they are logged.
+func deleteBlobs(ctx context.Context, blobs blob.Store, photo models.UserPhoto) {
+	for _, key := range photo.BlobKeys() {
+		if err := blobs.Delete(ctx, key); err != nil {
+			log.Printf("photo %s: blob %s left behind: %v", photo.ID.Hex(), key, err)
+		}
+	}
+}
+
 func (h *Handler) UploadPhotoHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID := r.Context().Value(middlewares.UserIDKey).(string)
 		objID, _ := primitive.ObjectIDFromHex(userID)
 
+		// Rejected photos don't take up one of the six slots
 		photoCol := h.DB.Collection("user_photos")
-		count, err := photoCol.CountDocuments(r.Context(), bson.M{"userId": objID})
+		count, err := photoCol.CountDocuments(r.Context(), bson.M{"userId": objID, "status": bson.M{"$ne": models.PhotoRejected}})
 		if err != nil {
 			http.Error(w, "Could not verify photo count", http.StatusInternalServerError)
 			return
@@ -33,6 +56,21 @@ func (h *Handler) UploadPhotoHandler() http.HandlerFunc {
 			return
 		}
 
+		// New photos go last. Rejected photos keep their order, so it can't come from count.
+		order := 0
+		var last models.UserPhoto
+		err = photoCol.FindOne(r.Context(), bson.M{"userId": objID},
+			options.FindOne().SetSort(bson.D{{Key: "order", Value: -1}}).SetProjection(bson.M{"order": 1}),
+		).Decode(&last)
+		switch {
+		case err == nil:
+			order = last.Order + 1
+		case !errors.Is(err, mongo.ErrNoDocuments):
+			http.Error(w, "Could not verify photo count", http.StatusInternalServerError)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxPhotoUploadBytes)
 		file, header, err := r.FormFile("photo")
 		if err != nil {
 			http.Error(w, "Failed to read file", http.StatusBadRequest)
@@ -52,27 +90,107 @@ func (h *Handler) UploadPhotoHandler() http.HandlerFunc {
 			return
 		}
 
+		// Never store what the client sent: decode, orient and re-encode, which also drops EXIF/GPS
+		storedMIME, renditions, err := imaging.Process(data, mime)
+		switch {
+		case errors.Is(err, imaging.ErrMismatch), errors.Is(err, imaging.ErrUnsupported):
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		case errors.Is(err, imaging.ErrTooLarge):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			http.Error(w, "Could not read image", http.StatusUnprocessableEntity)
+			return
+		}
+
 		photo := models.UserPhoto{
+			ID:        primitive.NewObjectID(),
 			UserID:    objID,
-			Data:      data,
-			MimeType:  mime,
-			Order:     int(count),
+			Order:     order,
 			CreatedAt: time.Now(),
 		}
 
+		// Bytes first: a record never points at a missing blob
+		if err := imaging.StoreRenditions(r.Context(), h.Blobs, &photo, storedMIME, renditions); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Failed to store photo",
+				err.Error(),
+			)
+			return
+		}
+
+		// Nothing goes live before the classifier, and maybe a moderator, has seen it
+		err = h.Moderation.Screen(r.Context(), &photo, moderation.Input{
+			MimeType: storedMIME,
+			Card:     cardRendition(renditions),
+			Width:    photo.Width,
+			Height:   photo.Height,
+		})
+		if err != nil {
+			deleteBlobs(r.Context(), h.Blobs, photo)
+			http.Error(w, "Failed to store photo", http.StatusInternalServerError)
+			return
+		}
+
 		_, err = h.DB.Collection("user_photos").InsertOne(r.Context(), photo)
 		if err != nil {
+			deleteBlobs(r.Context(), h.Blobs, photo)
 			http.Error(w, "Failed to store photo", http.StatusInternalServerError)
 			return
 		}
+		refreshCompleteness(r.Context(), h.DB, objID)
+
+		message := "Photo uploaded"
+		switch photo.Status {
+		case models.PhotoPending:
+			message = "Photo uploaded, it will show once a moderator has approved it"
+		case models.PhotoRejected:
+			message = "Photo rejected"
+		}
 
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
-		w.Write([]byte(`{"message":"Photo uploaded"}`))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": message,
+			"photo":   h.photoRef(photo, objID, time.Now()),
+		})
 	}
 }
 
+// cardRendition picks the card size out of a processed upload, which is what the classifier looks at
+func cardRendition(renditions []imaging.Rendition) []byte {
+	for _, r := range renditions {
+		if r.Size == "card" {
+			return r.Data
+		}
+	}
+	return renditions[len(renditions)-1].Data
+}
+
+// photosBlocked reports whether viewer may not see owner's photos
+func (h *Handler) photosBlocked(ctx context.Context, w http.ResponseWriter, viewer, owner primitive.ObjectID) bool {
+	if viewer == owner {
+		return false
+	}
+	blocked, err := isBlocked(ctx, h.DB, viewer, owner)
+	if err != nil {
+		http.Error(w, "Error loading photos", http.StatusInternalServerError)
+		return true
+	}
+	if blocked {
+		http.Error(w, "Photo not found", http.StatusNotFound)
+		return true
+	}
+	return false
+}
+
+// GetUserPhotosHandler handles GET /api/auth/users/{userId}/photos with URLs signed for the caller
 func (h *Handler) GetUserPhotosHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		viewerID := r.Context().Value(middlewares.UserIDKey).(string)
+		viewer, _ := primitive.ObjectIDFromHex(viewerID)
+
 		userID := mux.Vars(r)["userId"]
 		objID, err := primitive.ObjectIDFromHex(userID)
 		if err != nil {
@@ -83,9 +201,17 @@ func (h *Handler) GetUserPhotosHandler() http.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		if h.photosBlocked(ctx, w, viewer, objID) {
+			return
+		}
+
+		filter := bson.M{"userId": objID}
+		if viewer != objID {
+			filter = approvedPhotos(filter)
+		}
 		cursor, err := h.DB.Collection("user_photos").Find(
 			ctx,
-			bson.M{"userId": objID},
+			filter,
 			options.Find().SetSort(bson.D{{Key: "order", Value: 1}}),
 		)
 		if err != nil {
@@ -99,18 +225,33 @@ func (h *Handler) GetUserPhotosHandler() http.HandlerFunc {
 			return
 		}
 
-		// Optional: hide binary data if you’re just listing references or IDs
-		for i := range photos {
-			photos[i].Data = nil
+		now := time.Now()
+		refs := make([]models.PhotoRef, 0, len(photos))
+		for _, p := range photos {
+			refs = append(refs, h.photoRef(p, viewer, now))
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(photos)
+		json.NewEncoder(w).Encode(refs)
+	}
+}
+
+// photoSize reads ?size=, defaulting to the full size
+func photoSize(r *http.Request) (string, bool) {
+	size := r.URL.Query().Get("size")
+	if size == "" {
+		return models.FullPhotoSize, true
 	}
+	return size, validation.In(size, imaging.SizeNames()...)
 }
 
+// GetUserPhotoHandler serves a user's first photo in ?size=thumb|card|full. Which photo that is
+// changes with a reorder, so this is always revalidated; signed photo URLs are the cacheable ones.
 func (h *Handler) GetUserPhotoHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		viewerID := r.Context().Value(middlewares.UserIDKey).(string)
+		viewer, _ := primitive.ObjectIDFromHex(viewerID)
+
 		vars := mux.Vars(r)
 		userID := vars["userId"]
 		objID, err := primitive.ObjectIDFromHex(userID)
@@ -119,15 +260,30 @@ func (h *Handler) GetUserPhotoHandler() http.HandlerFunc {
 			return
 		}
 
+		size, ok := photoSize(r)
+		if !ok {
+			http.Error(w, "size must be one of "+strings.Join(imaging.SizeNames(), ", "), http.StatusBadRequest)
+			return
+		}
+
+		if h.photosBlocked(r.Context(), w, viewer, objID) {
+			return
+		}
+
+		filter := bson.M{"userId": objID}
+		if viewer != objID {
+			filter = approvedPhotos(filter)
+		}
 		var photo models.UserPhoto
-		err = h.DB.Collection("user_photos").FindOne(r.Context(), bson.M{"userId": objID}).Decode(&photo)
+		err = h.DB.Collection("user_photos").FindOne(r.Context(), filter,
+			options.FindOne().SetSort(bson.D{{Key: "order", Value: 1}}),
+		).Decode(&photo)
 		if err != nil {
 			http.Error(w, "Photo not found", http.StatusNotFound)
 			return
 		}
 
-		w.Header().Set("Content-Type", photo.MimeType)
-		w.Write(photo.Data)
+		h.servePhoto(w, r, photo, size, revalidateCache)
 	}
 }
 
@@ -196,12 +352,13 @@ func (h *Handler) DeletePhotoHandler() http.HandlerFunc {
 			return
 		}
 
-		// Delete the photo
+		// Delete the photo; the record first, so a leftover blob is never served
 		_, err = photoCol.DeleteOne(r.Context(), bson.M{"_id": objID})
 		if err != nil {
 			http.Error(w, "Failed to delete photo", http.StatusInternalServerError)
 			return
 		}
+		deleteBlobs(r.Context(), h.Blobs, photo)
 
 		// Fetch remaining photos, sorted by order
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -228,6 +385,7 @@ func (h *Handler) DeletePhotoHandler() http.HandlerFunc {
 				)
 			}
 		}
+		refreshCompleteness(ctx, h.DB, userObjID)
 
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/imaging"
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/moderation"
+	"ships-backend/internal/validation"
+)
+
+// Anything not addressed by a signed, versioned URL may change, e.g. a user's first photo after a reorder
+const revalidateCache = "private, no-cache"
+
+// photoVersion identifies the bytes of one size of a photo; it goes into URLs as ?v=
+func photoVersion(v models.PhotoVariant) string {
+	if len(v.Checksum) > 16 {
+		return v.Checksum[:16]
+	}
+	return v.Checksum
+}
+
+// approvedPhotos limits a user_photos filter to what other users may see
+func approvedPhotos(filter bson.M) bson.M {
+	filter["status"] = models.PhotoApproved
+	return filter
+}
+
+// photoRef lists signed URLs for every size of a photo. They only work for viewer. The owner also
+// sees the moderation status and, for a rejected photo, why.
+func (h *Handler) photoRef(photo models.UserPhoto, viewer primitive.ObjectID, now time.Time) models.PhotoRef {
+	ref := models.PhotoRef{
+		ID:     photo.ID,
+		Order:  photo.Order,
+		Width:  photo.Width,
+		Height: photo.Height,
+		URLs:   map[string]string{},
+	}
+	for _, size := range imaging.SizeNames() {
+		ref.URLs[size] = h.PhotoURLs.URL(photo.ID, viewer, size, photoVersion(photo.Variant(size)), now)
+	}
+
+	if viewer == photo.UserID {
+		ref.Status = photo.Status
+		if reason, ok := moderation.ReasonByID(photo.Moderation.Reason); ok && photo.Status == models.PhotoRejected {
+			ref.RejectReason = strings.TrimSpace(reason.Message + " " + photo.Moderation.Note)
+		}
+	}
+	return ref
+}
+
+// photoRefsByUser loads the photos of several users at once, in display order, as viewer sees them
+func (h *Handler) photoRefsByUser(ctx context.Context, viewer primitive.ObjectID, userIDs []primitive.ObjectID) (map[primitive.ObjectID][]models.PhotoRef, error) {
+	cursor, err := h.DB.Collection("user_photos").Find(ctx,
+		approvedPhotos(bson.M{"userId": bson.M{"$in": userIDs}}),
+		options.Find().SetSort(bson.D{{Key: "userId", Value: 1}, {Key: "order", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var photos []models.UserPhoto
+	if err := cursor.All(ctx, &photos); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	refs := map[primitive.ObjectID][]models.PhotoRef{}
+	for _, p := range photos {
+		refs[p.UserID] = append(refs[p.UserID], h.photoRef(p, viewer, now))
+	}
+	return refs, nil
+}
+
+// withPhotos fills in the photos of each card with URLs signed for viewer. A failure leaves cards
+// without photos rather than failing the whole list.
+func (h *Handler) withPhotos(ctx context.Context, viewer primitive.ObjectID, cards []models.PublicCard) []models.PublicCard {
+	ids := make([]primitive.ObjectID, 0, len(cards))
+	for _, c := range cards {
+		ids = append(ids, c.ID)
+	}
+	refs, err := h.photoRefsByUser(ctx, viewer, ids)
+	if err != nil {
+		log.Printf("loading photos for cards: %v", err)
+	}
+	for i := range cards {
+		cards[i].Photos = refs[cards[i].ID]
+		if cards[i].Photos == nil {
+			cards[i].Photos = []models.PhotoRef{}
+		}
+	}
+	return cards
+}
+
+// servePhoto writes one size of a photo with caching headers. http.ServeContent takes care of
+// If-None-Match / If-Modified-Since (304), Range requests and HEAD.
+func (h *Handler) servePhoto(w http.ResponseWriter, r *http.Request, photo models.UserPhoto, size, cacheControl string) {
+	variant := photo.Variant(size)
+
+	blob, err := h.Blobs.Get(r.Context(), variant.Key)
+	if err != nil {
+		http.Error(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+	defer blob.Close()
+	// Photos are small; ServeContent needs to seek for ranges
+	data, err := io.ReadAll(blob)
+	if err != nil {
+		http.Error(w, "Could not read photo", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", photo.MimeType)
+	w.Header().Set("ETag", `"`+variant.Checksum+`"`)
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, "", photo.CreatedAt, bytes.NewReader(data))
+}
+
+// SignedPhotoHandler handles GET /api/public/photos/{photoId}?size=&v=&viewer=&exp=&sig=, the URLs
+// handed out in photo refs. The signature stands in for the JWT; blocks and deletions are checked
+// on every request, so those URLs stop working right away.
+func (h *Handler) SignedPhotoHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		photoID, err := primitive.ObjectIDFromHex(mux.Vars(r)["photoId"])
+		if err != nil {
+			http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		grant, err := h.PhotoURLs.Verify(photoID, r.URL.Query(), now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		var photo models.UserPhoto
+		if err := h.DB.Collection("user_photos").FindOne(ctx, bson.M{"_id": photoID}).Decode(&photo); err != nil {
+			http.Error(w, "Photo not found", http.StatusNotFound)
+			return
+		}
+		if !validation.In(grant.Size, imaging.SizeNames()...) {
+			http.Error(w, "Photo not found", http.StatusNotFound)
+			return
+		}
+
+		if grant.Viewer != photo.UserID {
+			blocked, err := isBlocked(ctx, h.DB, grant.Viewer, photo.UserID)
+			if err != nil {
+				http.Error(w, "Error loading photo", http.StatusInternalServerError)
+				return
+			}
+			// Pending and rejected photos are for their owner and moderators only
+			hidden := false
+			if photo.Status != models.PhotoApproved {
+				admin, err := middlewares.IsAdmin(ctx, h.DB, grant.Viewer)
+				if err != nil {
+					http.Error(w, "Error loading photo", http.StatusInternalServerError)
+					return
+				}
+				hidden = !admin
+			}
+			if blocked || hidden {
+				http.Error(w, "Photo not found", http.StatusNotFound)
+				return
+			}
+		}
+
+		// The bytes behind a version never change, but the URL is only good until it expires
+		cacheControl := revalidateCache
+		if grant.Version == photoVersion(photo.Variant(grant.Size)) {
+			maxAge := int(grant.ExpiresAt.Sub(now).Seconds())
+			cacheControl = "private, max-age=" + strconv.Itoa(maxAge) + ", immutable"
+		}
+		h.servePhoto(w, r.WithContext(ctx), photo, grant.Size, cacheControl)
+	}
+}
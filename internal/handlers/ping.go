@@ -39,23 +39,38 @@ func (h *Handler) PingLocationHandler() http.HandlerFunc {
 
 		now := time.Now()
 
-		// Update user’s location and updatedAt
-		_, err := h.DB.Collection("users").UpdateByID(ctx, objID, bson.M{
+		// Update user’s location and when they were last active. updatedAt is left alone: it versions the profile.
+		var me models.User
+		err := h.DB.Collection("users").FindOneAndUpdate(ctx, bson.M{"_id": objID}, bson.M{
 			"$set": bson.M{
 				"location": bson.M{
 					"type":        "Point",
 					"coordinates": req.Coordinates,
 				},
-				"updatedAt": now,
+				"lastActiveAt": now,
 			},
-		})
+		}).Decode(&me)
 		if err != nil {
 			http.Error(w, "Failed to update location", http.StatusInternalServerError)
 			return
 		}
 
+		// The first real location completes a checklist step
+		if !me.HasLocation() {
+			refreshCompleteness(ctx, h.DB, objID)
+		}
+
+		// Paused users don't cross paths with anyone
+		if me.IsPaused(now) {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": "Location updated",
+			})
+			return
+		}
+
 		// Find nearby users who were recently active
-		nearbyCursor, err := h.DB.Collection("users").Find(ctx, bson.M{
+		nearbyCursor, err := h.DB.Collection("users").Find(ctx, visibleUsers(bson.M{
 			"_id": bson.M{"$ne": objID},
 			"location": bson.M{
 				"$near": bson.M{
@@ -66,10 +81,10 @@ func (h *Handler) PingLocationHandler() http.HandlerFunc {
 					"$maxDistance": 100, // 100 meters
 				},
 			},
-			"updatedAt": bson.M{
+			"lastActiveAt": bson.M{
 				"$gte": now.Add(-10 * time.Minute), // seen recently
 			},
-		})
+		}))
 		if err != nil {
 			http.Error(w, "Nearby scan failed", http.StatusInternalServerError)
 			return
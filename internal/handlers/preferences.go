@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+	"ships-backend/internal/validation"
+)
+
+// GetPreferencesHandler handles GET /api/auth/preferences, defaults included
+func (h *Handler) GetPreferencesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := h.DB.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(user.Preferences.WithDefaults())
+	}
+}
+
+// UpdatePreferencesHandler handles PUT /api/auth/preferences. Members left out fall back to the defaults.
+func (h *Handler) UpdatePreferencesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		var req models.DiscoveryPreferences
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		prefs := req.WithDefaults()
+
+		v := validation.New()
+		v.Preferences("preferences", prefs)
+		if !v.Valid() {
+			utils.RespondWithFieldErrors(w, v.Errors)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, err := h.DB.Collection("users").UpdateByID(ctx, objID, bson.M{
+			"$set": bson.M{"preferences": prefs},
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not save your preferences.",
+				err.Error(),
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(prefs)
+	}
+}
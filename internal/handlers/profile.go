@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"ships-backend/internal/catalog"
 	"ships-backend/internal/middlewares"
 	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+	"ships-backend/internal/validation"
 )
 
 func GetProfileHandler(db *mongo.Database) http.HandlerFunc {
@@ -30,6 +37,8 @@ func GetProfileHandler(db *mongo.Database) http.HandlerFunc {
 		}
 
 		user.Password = ""
+		w.Header().Set("ETag", profileETag(user.UpdatedAt))
+		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(user)
 	}
 }
@@ -45,26 +54,325 @@ func UpdateProfileHandler(db *mongo.Database) http.HandlerFunc {
 			return
 		}
 
+		update.Name = strings.TrimSpace(update.Name)
+
+		v := validation.New()
+		v.Name("name", update.Name)
+		v.Bio("bio", update.Bio)
+		if update.Gender != "" {
+			v.Gender("gender", update.Gender)
+		}
+		v.Interests("interests", update.Interests)
+		if update.Location != nil {
+			v.Location("location", *update.Location)
+		}
+		if !v.Valid() {
+			utils.RespondWithFieldErrors(w, v.Errors)
+			return
+		}
+
 		// Email is not updated here on purpose
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		_, err := db.Collection("users").UpdateByID(ctx, objID, bson.M{
-			"$set": bson.M{
-				"name":      update.Name,
-				"bio":       update.Bio,
-				"gender":    update.Gender,
-				"interests": update.Interests,
-				"location":  update.Location,
-				"updatedAt": time.Now(),
-			},
-		})
+		set := bson.M{
+			"name":      update.Name,
+			"bio":       update.Bio,
+			"gender":    update.Gender,
+			"interests": update.Interests,
+			"updatedAt": time.Now(),
+		}
+		if update.Location != nil {
+			set["location"] = update.Location
+		}
+
+		_, err := db.Collection("users").UpdateByID(ctx, objID, bson.M{"$set": set})
 
 		if err != nil {
 			http.Error(w, "Failed to update profile", http.StatusInternalServerError)
 			return
 		}
+		refreshCompleteness(ctx, db, objID)
 
 		json.NewEncoder(w).Encode(map[string]string{"message": "Profile updated successfully."})
 	}
 }
+
+// profileETag versions the profile by its updatedAt, in milliseconds since that is what MongoDB stores
+func profileETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMilli(), 10) + `"`
+}
+
+// parseIfMatch returns the updatedAt an If-Match header refers to; wildcard reports "If-Match: *"
+func parseIfMatch(header string) (updatedAt time.Time, wildcard bool, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return time.Time{}, true, true
+	}
+	ms, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	return time.UnixMilli(ms), false, true
+}
+
+// patchField applies one member of a merge patch. null means "remove" for fields that allow it.
+type patchField func(v *validation.Validator, raw json.RawMessage, set, unset bson.M)
+
+// profilePatchFields are the members PATCH /profile accepts
+var profilePatchFields = map[string]patchField{
+	"name": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		var name string
+		if decodePatchValue(v, "name", raw, &name) {
+			name = strings.TrimSpace(name)
+			v.Name("name", name)
+			set["name"] = name
+		}
+	},
+	"bio": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		if isJSONNull(raw) {
+			unset["bio"] = ""
+			return
+		}
+		var bio string
+		if decodePatchValue(v, "bio", raw, &bio) {
+			v.Bio("bio", bio)
+			set["bio"] = bio
+		}
+	},
+	"gender": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		if isJSONNull(raw) {
+			set["gender"] = ""
+			return
+		}
+		var gender string
+		if decodePatchValue(v, "gender", raw, &gender) {
+			v.Gender("gender", gender)
+			set["gender"] = gender
+		}
+	},
+	"interests": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		if isJSONNull(raw) {
+			set["interests"] = []string{}
+			return
+		}
+		var interests []string
+		if decodePatchValue(v, "interests", raw, &interests) {
+			v.Interests("interests", interests)
+			set["interests"] = interests
+		}
+	},
+	"location": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		var location models.Location
+		if decodePatchValue(v, "location", raw, &location) {
+			v.Location("location", location)
+			set["location"] = location
+		}
+	},
+	"heightCm": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		if isJSONNull(raw) {
+			unset["heightCm"] = ""
+			return
+		}
+		var cm int
+		if decodePatchValue(v, "heightCm", raw, &cm) {
+			v.HeightCm("heightCm", cm)
+			set["heightCm"] = cm
+		}
+	},
+	"occupation": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		if isJSONNull(raw) {
+			unset["occupation"] = ""
+			return
+		}
+		var occupation string
+		if decodePatchValue(v, "occupation", raw, &occupation) {
+			occupation = strings.TrimSpace(occupation)
+			v.Required("occupation", occupation)
+			v.MaxLength("occupation", occupation, validation.MaxOccupationLength)
+			set["occupation"] = occupation
+		}
+	},
+	"education":        enumPatchField("education", catalog.EducationLevels),
+	"relationshipGoal": enumPatchField("relationshipGoal", catalog.RelationshipGoals),
+	"languages": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		if isJSONNull(raw) {
+			unset["languages"] = ""
+			return
+		}
+		var languages []string
+		if decodePatchValue(v, "languages", raw, &languages) {
+			v.Languages("languages", languages)
+			set["languages"] = languages
+		}
+	},
+	"lifestyle": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		if isJSONNull(raw) {
+			unset["lifestyle"] = ""
+			return
+		}
+		// Merged member by member like the top level
+		var members map[string]json.RawMessage
+		if !decodePatchValue(v, "lifestyle", raw, &members) {
+			return
+		}
+		answers := map[string][]string{
+			"drinking": catalog.DrinkingOptions,
+			"smoking":  catalog.SmokingOptions,
+			"kids":     catalog.KidsOptions,
+		}
+		for name, value := range members {
+			allowed, ok := answers[name]
+			if !ok {
+				v.AddError("lifestyle."+name, "cannot be changed here")
+				continue
+			}
+			enumPatchField("lifestyle."+name, allowed)(v, value, set, unset)
+		}
+	},
+	"prompts": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		if isJSONNull(raw) {
+			unset["prompts"] = ""
+			return
+		}
+		var prompts []models.ProfilePrompt
+		if decodePatchValue(v, "prompts", raw, &prompts) {
+			v.Prompts("prompts", prompts)
+			set["prompts"] = prompts
+		}
+	},
+	"visibility": func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		var members map[string]json.RawMessage
+		if !decodePatchValue(v, "visibility", raw, &members) {
+			return
+		}
+		for name, value := range members {
+			field := "visibility." + name
+			if !validation.In(name, models.DetailFields...) {
+				v.AddError(field, "has no visibility setting")
+				continue
+			}
+			enumPatchField(field, catalog.Visibilities)(v, value, set, unset)
+		}
+	},
+}
+
+// enumPatchField handles an optional string field restricted to catalog values; path is also the error key
+func enumPatchField(path string, allowed []string) patchField {
+	return func(v *validation.Validator, raw json.RawMessage, set, unset bson.M) {
+		if isJSONNull(raw) {
+			unset[path] = ""
+			return
+		}
+		var value string
+		if decodePatchValue(v, path, raw, &value) {
+			v.Enum(path, value, allowed)
+			set[path] = value
+		}
+	}
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
+
+// decodePatchValue unmarshals raw into dst and records a field error when that fails.
+// Fields that can be removed check for null before calling it.
+func decodePatchValue(v *validation.Validator, field string, raw json.RawMessage, dst any) bool {
+	if isJSONNull(raw) {
+		v.AddError(field, "cannot be removed")
+		return false
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		v.AddError(field, "has the wrong type")
+		return false
+	}
+	return true
+}
+
+// PatchProfileHandler handles PATCH /api/auth/profile with JSON merge patch semantics (RFC 7396):
+// only the members present are changed. The request must carry If-Match with the ETag from
+// GET /profile; when another device changed the profile in between it fails with 412.
+func PatchProfileHandler(db *mongo.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ifMatch := r.Header.Get("If-Match")
+		if ifMatch == "" {
+			utils.RespondWithError(w, http.StatusPreconditionRequired,
+				"If-Match header with the profile ETag is required",
+				"profile patch without If-Match for user "+userID,
+			)
+			return
+		}
+		version, anyVersion, ok := parseIfMatch(ifMatch)
+		if !ok {
+			http.Error(w, "Invalid If-Match header", http.StatusBadRequest)
+			return
+		}
+
+		var patch map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		v := validation.New()
+		set, unset := bson.M{}, bson.M{}
+		for field, raw := range patch {
+			apply, ok := profilePatchFields[field]
+			if !ok {
+				v.AddError(field, "cannot be changed here")
+				continue
+			}
+			apply(v, raw, set, unset)
+		}
+		if !v.Valid() {
+			utils.RespondWithFieldErrors(w, v.Errors)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		filter := bson.M{"_id": objID}
+		if !anyVersion {
+			filter["updatedAt"] = version
+		}
+
+		set["updatedAt"] = time.Now()
+		update := bson.M{"$set": set}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+
+		var user models.User
+		err := db.Collection("users").FindOneAndUpdate(ctx, filter, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&user)
+		if err == mongo.ErrNoDocuments {
+			var current models.User
+			if err := db.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&current); err != nil {
+				http.Error(w, "User not found", http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", profileETag(current.UpdatedAt))
+			utils.RespondWithError(w, http.StatusPreconditionFailed,
+				"Your profile was changed on another device. Reload it and try again.",
+				"profile patch version mismatch for user "+userID,
+			)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+			return
+		}
+		refreshCompleteness(ctx, db, objID)
+
+		user.Password = ""
+		w.Header().Set("ETag", profileETag(user.UpdatedAt))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(user)
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/validation"
+)
+
+const earthRadiusMeters = 6371000
+
+// discoveryResult is a user as $geoNear returns it, with the distance to the caller in meters
+type discoveryResult struct {
+	models.User `bson:",inline"`
+	Distance    float64 `bson:"distance"`
+}
+
+// publicCard is the only way other users' profiles leave the API. distanceMeters may be nil, in
+// which case it is worked out from both stored locations.
+func publicCard(u models.User, viewer models.User, distanceMeters *float64, matched bool, now time.Time) models.PublicCard {
+	u.Redact(matched)
+
+	card := models.PublicCard{
+		ID:             u.ID,
+		Name:           u.Name,
+		Age:            validation.Age(u.Birth, now),
+		Gender:         u.Gender,
+		Bio:            u.Bio,
+		Interests:      u.Interests,
+		Matched:        matched,
+		ProfileDetails: u.ProfileDetails,
+	}
+	if card.Interests == nil {
+		card.Interests = []string{}
+	}
+
+	if distanceMeters == nil && u.HasLocation() && viewer.HasLocation() {
+		d := haversineMeters(u.Location, viewer.Location)
+		distanceMeters = &d
+	}
+	if distanceMeters != nil {
+		km := roundDistanceKm(*distanceMeters)
+		card.DistanceKm = &km
+	}
+	return card
+}
+
+// roundDistanceKm rounds up to whole kilometers, so "1 km" covers anyone closer
+func roundDistanceKm(meters float64) int {
+	return int(math.Max(1, math.Ceil(meters/1000)))
+}
+
+// haversineMeters is the great-circle distance between two GeoJSON points
+func haversineMeters(a, b models.Location) float64 {
+	lng1, lat1 := a.Coordinates[0]*math.Pi/180, a.Coordinates[1]*math.Pi/180
+	lng2, lat2 := b.Coordinates[0]*math.Pi/180, b.Coordinates[1]*math.Pi/180
+
+	h := math.Pow(math.Sin((lat2-lat1)/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin((lng2-lng1)/2), 2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
+
+// isMatched reports whether a and b share an open match
+func isMatched(ctx context.Context, db *mongo.Database, a, b primitive.ObjectID) (bool, error) {
+	match := models.NewMatch(a, b)
+	n, err := db.Collection("matches").CountDocuments(ctx, bson.M{
+		"user1":    match.User1,
+		"user2":    match.User2,
+		"closedAt": bson.M{"$exists": false},
+	})
+	return n > 0, err
+}
+
+// matchedIDs returns the users userID has an open match with
+func matchedIDs(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	cursor, err := db.Collection("matches").Find(ctx, bson.M{
+		"$or":      []bson.M{{"user1": userID}, {"user2": userID}},
+		"closedAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var matches []models.Match
+	if err := cursor.All(ctx, &matches); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[primitive.ObjectID]bool, len(matches))
+	for _, m := range matches {
+		if m.User1 == userID {
+			ids[m.User2] = true
+		} else {
+			ids[m.User1] = true
+		}
+	}
+	return ids, nil
+}
+
+// PublicProfileHandler handles GET /api/auth/users/{userId}. Deleted, paused and blocked users
+// (in either direction) are all 404, so nobody can tell which applies.
+func (h *Handler) PublicProfileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		otherID, err := primitive.ObjectIDFromHex(mux.Vars(r)["userId"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		blocked, err := isBlocked(ctx, h.DB, objID, otherID)
+		if err != nil {
+			http.Error(w, "Error loading user", http.StatusInternalServerError)
+			return
+		}
+		if blocked {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var other models.User
+		if err := h.DB.Collection("users").FindOne(ctx, visibleUsers(bson.M{"_id": otherID})).Decode(&other); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var me models.User
+		if err := h.DB.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&me); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		matched := false
+		if otherID != objID {
+			if matched, err = isMatched(ctx, h.DB, objID, otherID); err != nil {
+				http.Error(w, "Error loading user", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		cards := h.withPhotos(ctx, objID, []models.PublicCard{publicCard(other, me, nil, matched, time.Now())})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cards[0])
+	}
+}
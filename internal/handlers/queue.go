@@ -10,10 +10,11 @@ import (
 	"net/http"
 	"ships-backend/internal/middlewares"
 	"ships-backend/internal/models"
-	"strconv"
 	"time"
 )
 
+// SwipeQueueHandler handles GET /api/auth/queue. Candidates come from the stored location and
+// preferences, both ways (see discoveryPipeline), minus everyone already seen.
 func (h *Handler) SwipeQueueHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -22,74 +23,67 @@ func (h *Handler) SwipeQueueHandler() http.HandlerFunc {
 		userID := r.Context().Value(middlewares.UserIDKey).(string)
 		currentUserID, _ := primitive.ObjectIDFromHex(userID)
 
-		lat, _ := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
-		lng, _ := strconv.ParseFloat(r.URL.Query().Get("lng"), 64)
-		maxDistanceKm, _ := strconv.ParseFloat(r.URL.Query().Get("maxDistanceKm"), 64)
-		if maxDistanceKm == 0 {
-			maxDistanceKm = 5
+		me, ok := h.loadDiscoveryUser(ctx, w, currentUserID)
+		if !ok {
+			return
 		}
 
-		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
-		if limit == 0 {
-			limit = 10
-		}
+		limit, skip := pageParams(r)
 
 		// 🧠 Get seen users
 		var seen []models.Seen
 		cursor, _ := h.DB.Collection("seen").Find(ctx, bson.M{"userId": currentUserID})
 		_ = cursor.All(ctx, &seen)
 
-		seenIDs := map[string]bool{}
-		for _, s := range seen {
-			seenIDs[s.SeenUser.Hex()] = true
-		}
-
-		var exclude []primitive.ObjectID
-		for idStr := range seenIDs {
-			oid, err := primitive.ObjectIDFromHex(idStr)
-			if err == nil {
-				exclude = append(exclude, oid)
-			}
-		}
-
-		idFilter := bson.M{
-			"$ne": currentUserID,
+		blocked, err := blockedIDs(ctx, h.DB, currentUserID)
+		if err != nil {
+			http.Error(w, "Query failed", http.StatusInternalServerError)
+			return
 		}
 
-		if len(exclude) > 0 {
-			idFilter["$nin"] = exclude
+		exclude := append([]primitive.ObjectID{currentUserID}, blocked...)
+		for _, s := range seen {
+			exclude = append(exclude, s.SeenUser)
 		}
 
-		filter := bson.M{
-			"_id": idFilter,
-			"location": bson.M{
-				"$near": bson.M{
-					"$geometry": bson.M{
-						"type":        "Point",
-						"coordinates": []float64{lng, lat},
-					},
-					"$maxDistance": maxDistanceKm * 1000,
+		pipeline := append(discoveryPipeline(me, bson.M{"_id": bson.M{"$nin": exclude}}, time.Now()),
+			bson.D{{Key: "$facet", Value: bson.M{
+				"users": bson.A{
+					bson.M{"$skip": skip},
+					bson.M{"$limit": limit},
 				},
-			},
-		}
+				"total": bson.A{bson.M{"$count": "count"}},
+			}}},
+		)
 
-		opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(skip))
-		result, err := h.DB.Collection("users").Find(ctx, filter, opts)
+		result, err := h.DB.Collection("users").Aggregate(ctx, pipeline)
 		if err != nil {
 			log.Print(err.Error())
 			http.Error(w, "Query failed", http.StatusInternalServerError)
 			return
 		}
 
-		var users []models.User
-		if err := result.All(ctx, &users); err != nil {
+		var page []struct {
+			Users []discoveryResult `bson:"users"`
+			Total []struct {
+				Count int `bson:"count"`
+			} `bson:"total"`
+		}
+		if err := result.All(ctx, &page); err != nil || len(page) == 0 {
 			http.Error(w, "Failed to load users", http.StatusInternalServerError)
 			return
 		}
 
+		users := page[0].Users
+		count := 0
+		if len(page[0].Total) > 0 {
+			count = page[0].Total[0].Count
+		}
+
 		// Auto-track as "seen"
+		now := time.Now()
 		seenCol := h.DB.Collection("seen")
+		cards := make([]models.PublicCard, 0, len(users))
 		for _, u := range users {
 			seenCol.UpdateOne(ctx,
 				bson.M{"userId": currentUserID, "seenUser": u.ID},
@@ -100,16 +94,16 @@ func (h *Handler) SwipeQueueHandler() http.HandlerFunc {
 				},
 				options.Update().SetUpsert(true),
 			)
-			u.Password = "" // sanitize
+			cards = append(cards, publicCard(u.User, me, &u.Distance, false, now))
 		}
+		cards = h.withPhotos(ctx, me.ID, cards)
 
 		// Preload metadata
-		count, _ := h.DB.Collection("users").CountDocuments(ctx, filter)
-		hasMore := int64(skip+limit) < count
+		hasMore := skip+limit < count
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"users":   users,
+			"users":   cards,
 			"next":    skip + limit,
 			"hasMore": hasMore,
 		})
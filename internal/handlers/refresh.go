@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+)
+
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type RefreshResponse struct {
+	Token        string `json:"authToken"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// issueRefreshToken mints a new refresh token in the given family and stores only its hash
+func issueRefreshToken(ctx context.Context, db *mongo.Database, userID, familyID primitive.ObjectID, r *http.Request) (string, error) {
+	token := utils.GenerateRandomToken(64)
+	now := time.Now()
+
+	_, err := db.Collection("refresh_tokens").InsertOne(ctx, models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(token),
+		UserAgent: r.UserAgent(),
+		IP:        utils.GetClientIP(r),
+		CreatedAt: now,
+		ExpiresAt: now.Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// revokeRefreshFamily revokes every token minted from the same login
+func revokeRefreshFamily(ctx context.Context, db *mongo.Database, userID, familyID primitive.ObjectID) error {
+	_, err := db.Collection("refresh_tokens").UpdateMany(ctx,
+		bson.M{"userId": userID, "familyId": familyID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	return err
+}
+
+// RefreshTokenHandler handles POST /api/public/auth/refresh
+func RefreshTokenHandler(db *mongo.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		tokens := db.Collection("refresh_tokens")
+
+		var stored models.RefreshToken
+		err := tokens.FindOne(ctx, bson.M{"tokenHash": utils.HashToken(req.RefreshToken)}).Decode(&stored)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Invalid refresh token",
+				err.Error(),
+			)
+			return
+		}
+
+		// A token that was already rotated or revoked is being replayed: kill the session and its family
+		if stored.UsedAt != nil || stored.RevokedAt != nil {
+			if _, err := revokeSession(ctx, db, stored.UserID, stored.FamilyID); err != nil {
+				log.Printf("Failed to revoke session %s: %v", stored.FamilyID.Hex(), err)
+			}
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Invalid refresh token",
+				"refresh token reuse detected for family "+stored.FamilyID.Hex(),
+			)
+			return
+		}
+
+		if time.Now().After(stored.ExpiresAt) {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Refresh token expired",
+				"refresh token expired for user "+stored.UserID.Hex(),
+			)
+			return
+		}
+
+		// The token is bound to the device that received it
+		if stored.UserAgent != r.UserAgent() {
+			_, _ = revokeSession(ctx, db, stored.UserID, stored.FamilyID)
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Invalid refresh token",
+				"refresh token presented from a different device for user "+stored.UserID.Hex(),
+			)
+			return
+		}
+
+		// The family is the session; a revoked or expired session can't be refreshed
+		session, err := extendSession(ctx, db, stored.UserID, stored.FamilyID, r)
+		if err != nil {
+			_ = revokeRefreshFamily(ctx, db, stored.UserID, stored.FamilyID)
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Session revoked or expired",
+				err.Error(),
+			)
+			return
+		}
+
+		// Mark as used atomically so two concurrent refreshes can't both succeed
+		res, err := tokens.UpdateOne(ctx,
+			bson.M{"_id": stored.ID, "usedAt": bson.M{"$exists": false}, "revokedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"usedAt": time.Now()}},
+		)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not refresh session",
+				err.Error(),
+			)
+			return
+		}
+		if res.ModifiedCount == 0 {
+			_, _ = revokeSession(ctx, db, stored.UserID, stored.FamilyID)
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Invalid refresh token",
+				"concurrent refresh token reuse for family "+stored.FamilyID.Hex(),
+			)
+			return
+		}
+
+		refreshToken, err := issueRefreshToken(ctx, db, stored.UserID, stored.FamilyID, r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not refresh session",
+				err.Error(),
+			)
+			return
+		}
+
+		token, err := utils.GenerateJWT(stored.UserID.Hex(), session.ID.Hex())
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Error generating token",
+				err.Error(),
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(RefreshResponse{
+			Token:        token,
+			RefreshToken: refreshToken,
+		})
+	}
+}
@@ -5,10 +5,10 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
-	"net/smtp"
+	"net/url"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -16,13 +16,20 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 
+	"ships-backend/internal/completeness"
+	"ships-backend/internal/mail"
 	"ships-backend/internal/models"
+	"ships-backend/internal/oidc"
+	"ships-backend/internal/ratelimit"
 	"ships-backend/internal/utils"
+	"ships-backend/internal/validation"
+	"ships-backend/internal/ws"
 )
 
 type RegisterResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token        string      `json:"token"`
+	User         models.User `json:"user"`
+	RefreshToken string      `json:"refreshToken"`
 }
 
 func generateRandomToken(n int) string {
@@ -31,40 +38,48 @@ func generateRandomToken(n int) string {
 	return hex.EncodeToString(b)
 }
 
-func sendVerificationEmail(toEmail, token string) {
-	from := "your@email.com"
-	pass := "your-password"
-	host := "smtp.yourprovider.com"
-	port := "587"
-
-	localIP := utils.GetLocalIP()
-	link := fmt.Sprintf("http://"+localIP+":8080/api/verify-email?token=%s", token)
-	body := "Click to verify your email: " + link
-
-	msg := "From: " + from + "\n" +
-		"To: " + toEmail + "\n" +
-		"Subject: Verify your email\n\n" + body
+func (h *AuthHandler) sendVerificationEmail(user models.User) {
+	h.mail.SendAsync(user.Email, "verify_email", map[string]any{
+		"Name": user.Name,
+		"Link": h.mail.Link("/api/public/verify-email", url.Values{"token": {user.VerifyToken}}),
+	})
+}
 
-	smtp.SendMail(host+":"+port,
-		smtp.PlainAuth("", from, pass, host),
-		from, []string{toEmail}, []byte(msg))
+func (h *AuthHandler) sendPasswordResetEmail(toEmail, token string) {
+	h.mail.SendAsync(toEmail, "password_reset", map[string]any{
+		"Link": h.mail.Link("/api/public/auth/password/reset", url.Values{"token": {token}}),
+	})
 }
 
 type AuthHandler struct {
-	db *mongo.Database
+	db           *mongo.Database
+	mail         *mail.Service
+	wsManager    *ws.Manager
+	accountGuard *ratelimit.Guard
+	ipGuard      *ratelimit.Guard
+
+	loginCodeGuard *ratelimit.Guard
+
+	oidcProviders map[string]*oidc.Provider
 }
 
-func NewAuthHandler(db *mongo.Database) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(db *mongo.Database, mailService *mail.Service, wsManager *ws.Manager, attempts ratelimit.AttemptStore, oidcProviders map[string]*oidc.Provider) *AuthHandler {
+	return &AuthHandler{
+		db:           db,
+		mail:         mailService,
+		wsManager:    wsManager,
+		accountGuard: ratelimit.NewGuard(attempts, accountLoginPolicy),
+		ipGuard:      ratelimit.NewGuard(attempts, ipLoginPolicy),
+
+		loginCodeGuard: ratelimit.NewGuard(attempts, loginCodeSendPolicy),
+
+		oidcProviders: oidcProviders,
+	}
 }
 
 func (h *AuthHandler) RegisterHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req struct {
-			Name     string `json:"name"`
-			Email    string `json:"email"`
-			Password string `json:"password"`
-		}
+		var req models.RegisterRequest
 
 		// Decode JSON manually (no c.BindJSON)
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -78,6 +93,28 @@ func (h *AuthHandler) RegisterHandler() http.HandlerFunc {
 			return
 		}
 
+		req.Email = strings.TrimSpace(req.Email)
+		req.Name = strings.TrimSpace(req.Name)
+
+		now := time.Now()
+		v := validation.New()
+		v.Name("name", req.Name)
+		v.Email("email", req.Email)
+		v.Password("password", req.Password)
+		v.Birth("birth", req.Birth, now)
+		if req.Gender != "" {
+			v.Gender("gender", req.Gender)
+		}
+		v.Bio("bio", req.Bio)
+		v.Interests("interests", req.Interests)
+		if req.Location != nil {
+			v.Location("location", *req.Location)
+		}
+		if !v.Valid() {
+			utils.RespondWithFieldErrors(w, v.Errors)
+			return
+		}
+
 		// Check if user already exists
 		var existing models.User
 		err := h.db.Collection("users").FindOne(context.Background(), bson.M{"email": req.Email}).Decode(&existing)
@@ -95,14 +132,25 @@ func (h *AuthHandler) RegisterHandler() http.HandlerFunc {
 			Name:          req.Name,
 			Email:         req.Email,
 			Password:      string(hashedPassword),
-			CreatedAt:     time.Now(),
+			Bio:           req.Bio,
+			Gender:        req.Gender,
+			Birth:         req.Birth,
+			Interests:     req.Interests,
+			CreatedAt:     now,
+			UpdatedAt:     now,
 			EmailVerified: false,
 			VerifyToken:   generateRandomToken(32),
+			VerifyExpires: now.Add(verifyTokenTTL),
+			VerifySentAt:  now,
 			Location: models.Location{
 				Type:        "Point",
 				Coordinates: []float64{0.0, 0.0}, // default empty location
 			},
 		}
+		if req.Location != nil {
+			user.Location = *req.Location
+		}
+		user.Completeness = completeness.Score(user, 0).Score
 
 		// Insert into MongoDB
 		_, err = h.db.Collection("users").InsertOne(context.Background(), user)
@@ -116,8 +164,8 @@ func (h *AuthHandler) RegisterHandler() http.HandlerFunc {
 			return
 		}
 
-		// Generate JWT
-		token, err := utils.GenerateJWT(user.ID.Hex())
+		// Sign the new device in
+		token, refreshToken, err := startSession(context.Background(), h.db, user.ID, r)
 		if err != nil {
 
 			utils.RespondWithError(w, http.StatusInternalServerError,
@@ -133,13 +181,14 @@ func (h *AuthHandler) RegisterHandler() http.HandlerFunc {
 		w.WriteHeader(http.StatusCreated)
 
 		err = json.NewEncoder(w).Encode(RegisterResponse{
-			Token: token,
-			User:  user,
+			Token:        token,
+			User:         user,
+			RefreshToken: refreshToken,
 		})
 		if err != nil {
 			log.Printf("Failed to encode response: %v", err)
 		}
 
-		go sendVerificationEmail(user.Email, user.VerifyToken)
+		h.sendVerificationEmail(user)
 	}
 }
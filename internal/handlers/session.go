@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/utils"
+)
+
+// Sessions live as long as their refresh tokens and are extended on every refresh
+const sessionTTL = refreshTokenTTL
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+}
+
+// startSession records a new device session and returns an access token and refresh token bound to it
+func startSession(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, r *http.Request) (string, string, error) {
+	now := time.Now()
+	session := models.AuthSession{
+		ID:         primitive.NewObjectID(),
+		UserID:     userID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(sessionTTL),
+		UserAgent:  r.UserAgent(),
+		IP:         utils.GetClientIP(r),
+	}
+
+	if _, err := db.Collection("auth_sessions").InsertOne(ctx, session); err != nil {
+		return "", "", err
+	}
+
+	// Sessions expire and get purged; the login history outlives them
+	_, err := db.Collection("login_events").InsertOne(ctx, models.LoginEvent{
+		UserID:    userID,
+		SessionID: session.ID,
+		IP:        session.IP,
+		UserAgent: session.UserAgent,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := utils.GenerateJWT(userID.Hex(), session.ID.Hex())
+	if err != nil {
+		return "", "", err
+	}
+
+	// The session ID is the refresh token family
+	refreshToken, err := issueRefreshToken(ctx, db, userID, session.ID, r)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+// extendSession slides an active session's expiry forward on refresh and records where it was seen
+func extendSession(ctx context.Context, db *mongo.Database, userID, sessionID primitive.ObjectID, r *http.Request) (models.AuthSession, error) {
+	now := time.Now()
+
+	var session models.AuthSession
+	err := db.Collection("auth_sessions").FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":       sessionID,
+			"userId":    userID,
+			"revokedAt": bson.M{"$exists": false},
+			"expiresAt": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{
+			"lastSeenAt": now,
+			"expiresAt":  now.Add(sessionTTL),
+			"ip":         utils.GetClientIP(r),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+
+	return session, err
+}
+
+// revokeSession revokes a single session of the user along with its refresh tokens.
+// It reports false when no active session matched.
+func revokeSession(ctx context.Context, db *mongo.Database, userID, sessionID primitive.ObjectID) (bool, error) {
+	res, err := db.Collection("auth_sessions").UpdateOne(ctx,
+		bson.M{"_id": sessionID, "userId": userID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if err := revokeRefreshFamily(ctx, db, userID, sessionID); err != nil {
+		return false, err
+	}
+
+	return res.MatchedCount > 0, nil
+}
+
+// revokeAllSessions signs the user out of every device
+func revokeAllSessions(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	now := time.Now()
+
+	_, err := db.Collection("auth_sessions").UpdateMany(ctx,
+		bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("refresh_tokens").UpdateMany(ctx,
+		bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now}},
+	)
+	return err
+}
+
+// ListSessionsHandler handles GET /api/auth/sessions
+func ListSessionsHandler(db *mongo.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		currentSessionID := r.Context().Value(middlewares.SessionIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := db.Collection("auth_sessions").Find(ctx,
+			bson.M{
+				"userId":    objID,
+				"revokedAt": bson.M{"$exists": false},
+				"expiresAt": bson.M{"$gt": time.Now()},
+			},
+			options.Find().SetSort(bson.D{{Key: "lastSeenAt", Value: -1}}),
+		)
+		if err != nil {
+			http.Error(w, "Failed to load sessions", http.StatusInternalServerError)
+			return
+		}
+		defer cursor.Close(ctx)
+
+		var sessions []models.AuthSession
+		if err := cursor.All(ctx, &sessions); err != nil {
+			http.Error(w, "Decode error", http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]SessionResponse, 0, len(sessions))
+		for _, s := range sessions {
+			response = append(response, SessionResponse{
+				ID:         s.ID.Hex(),
+				CreatedAt:  s.CreatedAt,
+				LastSeenAt: s.LastSeenAt,
+				ExpiresAt:  s.ExpiresAt,
+				UserAgent:  s.UserAgent,
+				IP:         s.IP,
+				Current:    s.ID.Hex() == currentSessionID,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// RevokeSessionHandler handles DELETE /api/auth/sessions/{sessionId}
+func RevokeSessionHandler(db *mongo.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		sessionID, err := primitive.ObjectIDFromHex(mux.Vars(r)["sessionId"])
+		if err != nil {
+			http.Error(w, "Invalid session ID", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		found, err := revokeSession(ctx, db, objID, sessionID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not revoke session.",
+				err.Error(),
+			)
+			return
+		}
+		if !found {
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// LogoutAllHandler handles POST /api/auth/logout-all
+func LogoutAllHandler(db *mongo.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := revokeAllSessions(ctx, db, objID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not logout.",
+				err.Error(),
+			)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"ships-backend/internal/middlewares"
 	"ships-backend/internal/models"
@@ -34,6 +35,17 @@ func (h *Handler) SwipeHandler() http.HandlerFunc {
 			return
 		}
 
+		blocked, err := isBlocked(ctx, h.DB, fromID, toID)
+		if err != nil {
+			http.Error(w, "Error checking user", http.StatusInternalServerError)
+			return
+		}
+		// Same answer as for a profile that doesn't exist
+		if blocked {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
 		var req SwipeRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
@@ -75,7 +87,22 @@ func (h *Handler) SwipeHandler() http.HandlerFunc {
 		if err == nil && (action == models.LikeSwipe || action == models.SuperLikeSwipe) {
 			// Mutual match!
 			match := models.NewMatch(fromID, toID)
-			_, _ = h.DB.Collection("matches").InsertOne(ctx, match)
+			_, err = h.DB.Collection("matches").InsertOne(ctx, match)
+			if mongo.IsDuplicateKeyError(err) {
+				// The pair matched before. A closed match (after a block) keeps its slot and stays closed.
+				err = h.DB.Collection("matches").FindOne(ctx, bson.M{"user1": match.User1, "user2": match.User2}).Decode(&match)
+				if err == nil && match.ClosedAt != nil {
+					w.WriteHeader(http.StatusCreated)
+					json.NewEncoder(w).Encode(map[string]any{
+						"match": false,
+					})
+					return
+				}
+			}
+			if err != nil {
+				http.Error(w, "Failed to create match", http.StatusInternalServerError)
+				return
+			}
 
 			// Notify both users via WebSocket (if connected)
 			h.WSManager.SendTo(fromID.Hex(), models.ChatMessagePayload{
@@ -148,11 +175,22 @@ func (h *Handler) GetYouGotLikedHandler() http.HandlerFunc {
 			}
 		}
 
+		var me models.User
+		if err := h.DB.Collection("users").FindOne(ctx, bson.M{"_id": currentUserID}).Decode(&me); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		blocked, err := blockedIDs(ctx, h.DB, currentUserID)
+		if err != nil {
+			http.Error(w, "Failed to load users", http.StatusInternalServerError)
+			return
+		}
+
 		// Step 4: Load user profiles
 		userCol := h.DB.Collection("users")
-		userCursor, err := userCol.Find(ctx, bson.M{
-			"_id": bson.M{"$in": likersToShow},
-		})
+		userCursor, err := userCol.Find(ctx, visibleUsers(bson.M{
+			"_id": bson.M{"$in": likersToShow, "$nin": blocked},
+		}))
 		if err != nil {
 			http.Error(w, "Failed to load users", http.StatusInternalServerError)
 			return
@@ -161,11 +199,15 @@ func (h *Handler) GetYouGotLikedHandler() http.HandlerFunc {
 		var users []models.User
 		_ = userCursor.All(ctx, &users)
 
-		for i := range users {
-			users[i].Password = ""
+		// Not matched yet: I haven't swiped back
+		now := time.Now()
+		cards := make([]models.PublicCard, 0, len(users))
+		for _, u := range users {
+			cards = append(cards, publicCard(u, me, nil, false, now))
 		}
+		cards = h.withPhotos(ctx, me.ID, cards)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(users)
+		json.NewEncoder(w).Encode(cards)
 	}
 }
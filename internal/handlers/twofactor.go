@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"ships-backend/internal/middlewares"
+	"ships-backend/internal/models"
+	"ships-backend/internal/totp"
+	"ships-backend/internal/utils"
+)
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute
+	recoveryCodeCount     = 10
+)
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// completeLogin finishes any successful first-factor login: users with 2FA get a challenge
+// token instead of a session
+func (h *AuthHandler) completeLogin(ctx context.Context, w http.ResponseWriter, r *http.Request, user models.User) {
+	if user.DeletedAt != nil {
+		utils.RespondWithErrorCode(w, http.StatusForbidden, ErrCodeAccountDeleted,
+			"This account has been deleted",
+			"login attempt on deleted account "+user.ID.Hex(),
+		)
+		return
+	}
+
+	if !user.TwoFactorEnabled {
+		h.respondWithLogin(ctx, w, r, user)
+		return
+	}
+
+	challenge, err := utils.GenerateChallengeJWT(user.ID.Hex(), twoFactorChallengeTTL)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError,
+			"Error generating token",
+			err.Error(),
+		)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge,
+	})
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code and consumes it
+func (h *AuthHandler) verifySecondFactor(ctx context.Context, user models.User, code, recoveryCode string) bool {
+	users := h.db.Collection("users")
+
+	if code != "" {
+		step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+		if !ok {
+			return false
+		}
+
+		// Record the step so the same code can't be replayed inside its window
+		res, err := users.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "$or": []bson.M{
+				{"totpLastStep": bson.M{"$exists": false}},
+				{"totpLastStep": bson.M{"$lt": step}},
+			}},
+			bson.M{"$set": bson.M{"totpLastStep": step}},
+		)
+		return err == nil && res.ModifiedCount == 1
+	}
+
+	if recoveryCode != "" {
+		hash := utils.HashToken(normalizeRecoveryCode(recoveryCode))
+		res, err := users.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "recoveryCodes": hash},
+			bson.M{"$pull": bson.M{"recoveryCodes": hash}},
+		)
+		return err == nil && res.ModifiedCount == 1
+	}
+
+	return false
+}
+
+// generateRecoveryCodes returns the codes to show the user once and the hashes to store
+func generateRecoveryCodes() ([]string, []string) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := utils.GenerateRandomToken(5)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = utils.HashToken(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// SetupTwoFactorHandler handles POST /api/auth/2fa/setup. The secret stays pending until confirmed.
+func (h *AuthHandler) SetupTwoFactorHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := h.db.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		if user.TwoFactorEnabled {
+			http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+			return
+		}
+
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not start two-factor setup.",
+				err.Error(),
+			)
+			return
+		}
+
+		_, err = h.db.Collection("users").UpdateByID(ctx, objID, bson.M{
+			"$set": bson.M{"totpPendingSecret": secret},
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not start two-factor setup.",
+				err.Error(),
+			)
+			return
+		}
+
+		issuer := os.Getenv("TOTP_ISSUER")
+		if issuer == "" {
+			issuer = "Ships"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(TwoFactorSetupResponse{
+			Secret:     secret,
+			OTPAuthURI: totp.URI(issuer, user.Email, secret),
+		})
+	}
+}
+
+// ConfirmTwoFactorHandler handles POST /api/auth/2fa/confirm and returns the recovery codes, once
+func (h *AuthHandler) ConfirmTwoFactorHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		var req TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := h.db.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		if user.TOTPPendingSecret == "" {
+			http.Error(w, "Start two-factor setup first", http.StatusBadRequest)
+			return
+		}
+
+		step, ok := totp.Validate(user.TOTPPendingSecret, req.Code, time.Now(), 0)
+		if !ok {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Invalid code",
+				"2fa confirmation failed for user "+userID,
+			)
+			return
+		}
+
+		codes, hashes := generateRecoveryCodes()
+		_, err := h.db.Collection("users").UpdateByID(ctx, objID, bson.M{
+			"$set": bson.M{
+				"twoFactorEnabled": true,
+				"totpSecret":       user.TOTPPendingSecret,
+				"totpLastStep":     step,
+				"recoveryCodes":    hashes,
+				"updatedAt":        time.Now(),
+			},
+			"$unset": bson.M{"totpPendingSecret": ""},
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not enable two-factor authentication.",
+				err.Error(),
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"recoveryCodes": codes})
+	}
+}
+
+// DisableTwoFactorHandler handles POST /api/auth/2fa/disable. It needs the password, or a recent
+// sign-in for accounts without one, and a current second factor.
+func (h *AuthHandler) DisableTwoFactorHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		sessionID := r.Context().Value(middlewares.SessionIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		var req DisableTwoFactorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := h.db.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		if !user.TwoFactorEnabled {
+			http.Error(w, "Two-factor authentication is not enabled", http.StatusConflict)
+			return
+		}
+
+		key := "2fa:" + userID
+		if decision := h.accountGuard.Check(key); !decision.Allowed {
+			respondThrottled(w, decision, ErrCodeTooManyAttempts,
+				"Too many failed attempts, try again later",
+				"2fa disable throttled for user "+userID,
+			)
+			return
+		}
+
+		if !h.reauthenticate(ctx, user, sessionID, DeleteAccountRequest(req)) {
+			h.accountGuard.Fail(key)
+			msg := "Invalid password or code"
+			if user.Password == "" {
+				msg = "Invalid code, or please sign in again first"
+			}
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				msg,
+				"2fa disable re-authentication failed for user "+userID,
+			)
+			return
+		}
+		h.accountGuard.Succeed(key)
+
+		_, err := h.db.Collection("users").UpdateByID(ctx, objID, bson.M{
+			"$set":   bson.M{"twoFactorEnabled": false, "updatedAt": time.Now()},
+			"$unset": bson.M{"totpSecret": "", "totpPendingSecret": "", "totpLastStep": "", "recoveryCodes": ""},
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not disable two-factor authentication.",
+				err.Error(),
+			)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// TwoFactorLoginHandler handles POST /api/public/auth/login/2fa, the second login step
+func (h *AuthHandler) TwoFactorLoginHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req TwoFactorLoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := utils.ValidateChallengeJWT(req.ChallengeToken)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Login expired, please sign in again",
+				err.Error(),
+			)
+			return
+		}
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		key := "2fa:" + userID
+		if decision := h.accountGuard.Check(key); !decision.Allowed {
+			respondThrottled(w, decision, ErrCodeTooManyAttempts,
+				"Too many failed attempts, try again later",
+				"2fa login throttled for user "+userID,
+			)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var user models.User
+		err = h.db.Collection("users").FindOne(ctx, bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}}).Decode(&user)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Login expired, please sign in again",
+				err.Error(),
+			)
+			return
+		}
+
+		if !user.TwoFactorEnabled || !h.verifySecondFactor(ctx, user, req.Code, req.RecoveryCode) {
+			h.accountGuard.Fail(key)
+			utils.RespondWithError(w, http.StatusUnauthorized,
+				"Invalid code",
+				"2fa login failed for user "+userID,
+			)
+			return
+		}
+		h.accountGuard.Succeed(key)
+
+		h.respondWithLogin(ctx, w, r, user)
+	}
+}
@@ -2,25 +2,63 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"net/http"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/middlewares"
 	"ships-backend/internal/models"
-	"time"
+	"ships-backend/internal/utils"
+)
+
+const (
+	verifyTokenTTL       = 48 * time.Hour
+	verifyResendCooldown = 2 * time.Minute
 )
 
-/*Use a library like gomail for HTML emails
+// appRedirect sends the browser into the app: to the deep link in envKey (fallback when unset)
+// with params added to its query. Emails link to our own https routes, which end up here, since
+// mail clients don't reliably make custom-scheme links clickable.
+func appRedirect(w http.ResponseWriter, r *http.Request, envKey, fallback string, params url.Values) {
+	target := os.Getenv(envKey)
+	if target == "" {
+		target = fallback
+	}
+
+	link, err := url.Parse(target)
+	if err != nil {
+		http.Error(w, "Invalid redirect configuration", http.StatusInternalServerError)
+		return
+	}
+	query := link.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	link.RawQuery = query.Encode()
 
-Automatically login user after verification
+	http.Redirect(w, r, link.String(), http.StatusFound)
+}
 
-Expire verification tokens after X hours*/
+// verifyRedirect sends the browser back into the app with the outcome, e.g. ships://email-verified?status=expired.
+// The deep link comes from APP_VERIFY_REDIRECT_URL.
+func verifyRedirect(w http.ResponseWriter, r *http.Request, status string) {
+	appRedirect(w, r, "APP_VERIFY_REDIRECT_URL", "ships://email-verified", url.Values{"status": {status}})
+}
 
-func VerifyEmailHandler(db *mongo.Database) http.HandlerFunc {
+// VerifyEmailHandler handles GET /api/public/verify-email?token=...
+func (h *AuthHandler) VerifyEmailHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		token := r.URL.Query().Get("token")
 		if token == "" {
-			http.Error(w, "Missing token", http.StatusBadRequest)
+			verifyRedirect(w, r, "invalid")
 			return
 		}
 
@@ -28,21 +66,94 @@ func VerifyEmailHandler(db *mongo.Database) http.HandlerFunc {
 		defer cancel()
 
 		var user models.User
-		err := db.Collection("users").FindOne(ctx, bson.M{"verifyToken": token}).Decode(&user)
+		err := h.db.Collection("users").FindOne(ctx, bson.M{"verifyToken": token}).Decode(&user)
 		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusNotFound)
+			verifyRedirect(w, r, "invalid")
 			return
 		}
 
-		_, err = db.Collection("users").UpdateByID(ctx, user.ID, bson.M{
-			"$set":   bson.M{"emailVerified": true},
-			"$unset": bson.M{"verifyToken": ""},
+		if time.Now().After(user.VerifyExpires) {
+			verifyRedirect(w, r, "expired")
+			return
+		}
+
+		_, err = h.db.Collection("users").UpdateByID(ctx, user.ID, bson.M{
+			"$set":   bson.M{"emailVerified": true, "updatedAt": time.Now()},
+			"$unset": bson.M{"verifyToken": "", "verifyTokenExpiresAt": ""},
 		})
 		if err != nil {
-			http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+			verifyRedirect(w, r, "error")
+			return
+		}
+		refreshCompleteness(ctx, h.db, user.ID)
+
+		verifyRedirect(w, r, "success")
+	}
+}
+
+// ResendVerificationHandler handles POST /api/auth/verify-email/resend.
+// A new token replaces the old one and resends are throttled per account.
+func (h *AuthHandler) ResendVerificationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middlewares.UserIDKey).(string)
+		objID, _ := primitive.ObjectIDFromHex(userID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now()
+		token := generateRandomToken(32)
+
+		var user models.User
+		err := h.db.Collection("users").FindOneAndUpdate(ctx,
+			bson.M{
+				"_id":           objID,
+				"emailVerified": false,
+				"$or": []bson.M{
+					{"verifySentAt": bson.M{"$exists": false}},
+					{"verifySentAt": bson.M{"$lte": now.Add(-verifyResendCooldown)}},
+				},
+			},
+			bson.M{"$set": bson.M{
+				"verifyToken":          token,
+				"verifyTokenExpiresAt": now.Add(verifyTokenTTL),
+				"verifySentAt":         now,
+			}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&user)
+
+		if err == mongo.ErrNoDocuments {
+			// Either already verified or asked too recently
+			var current models.User
+			if err := h.db.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&current); err != nil {
+				http.Error(w, "User not found", http.StatusNotFound)
+				return
+			}
+			if current.EmailVerified {
+				http.Error(w, "Email already verified", http.StatusConflict)
+				return
+			}
+
+			retryAfter := current.VerifySentAt.Add(verifyResendCooldown).Sub(now)
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			utils.RespondWithError(w, http.StatusTooManyRequests,
+				"Please wait before requesting another verification email",
+				"verification resend throttled for user "+userID,
+			)
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError,
+				"Could not resend verification email.",
+				err.Error(),
+			)
 			return
 		}
 
-		fmt.Fprint(w, "✅ Email verified successfully!")
+		h.sendVerificationEmail(user)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Verification email sent"})
 	}
 }
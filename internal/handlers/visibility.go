@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// visibleUsers narrows a users filter to accounts that may be shown to others: not deleted
+// and not paused. A pause whose PausedUntil has passed counts as resumed even before the
+// resumer clears it. Every query that lists other people goes through here.
+func visibleUsers(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": false}
+
+	notPaused := bson.M{"$or": []bson.M{
+		{"pausedAt": bson.M{"$exists": false}},
+		{"pausedUntil": bson.M{"$lte": time.Now()}},
+	}}
+	and, _ := filter["$and"].([]bson.M)
+	filter["$and"] = append(and, notPaused)
+
+	return filter
+}
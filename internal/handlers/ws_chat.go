@@ -60,6 +60,7 @@ func (h *Handler) WebSocketChatHandler() http.HandlerFunc {
 						{"user1": userObjID},
 						{"user2": userObjID},
 					},
+					"closedAt": bson.M{"$exists": false},
 				}).Decode(&match)
 				if err != nil {
 					continue
@@ -0,0 +1,39 @@
+// Package imaging inspects uploaded photos
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	_ "image/jpeg" // registers the decoders image.DecodeConfig uses
+	_ "image/png"
+)
+
+// Info is what gets recorded about stored image bytes
+type Info struct {
+	Width    int
+	Height   int
+	Size     int64
+	Checksum string // hex sha256
+}
+
+// Describe reads the dimensions from the image header and checksums the bytes
+func Describe(data []byte) (Info, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{
+		Width:    cfg.Width,
+		Height:   cfg.Height,
+		Size:     int64(len(data)),
+		Checksum: Checksum(data),
+	}, nil
+}
+
+// Checksum is the hex sha256 of data
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,168 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"ships-backend/internal/models"
+)
+
+// MaxPixels caps width*height before anything is decoded, so a small file can't expand into
+// gigabytes of pixels
+const MaxPixels = 40_000_000
+
+const jpegQuality = 85
+
+var (
+	ErrUnsupported = errors.New("only JPEG and PNG images are supported")
+	ErrMismatch    = errors.New("file contents don't match the declared type")
+	ErrTooLarge    = errors.New("image dimensions are too large")
+)
+
+// Size is one rendition of a photo, scaled to fit MaxSide on its longest edge. Smaller images
+// are never scaled up.
+type Size struct {
+	Name    string
+	MaxSide int
+}
+
+// Sizes from largest to smallest
+var Sizes = []Size{
+	{Name: models.FullPhotoSize, MaxSide: 1600},
+	{Name: "card", MaxSide: 640},
+	{Name: "thumb", MaxSide: 160},
+}
+
+// SizeNames lists the values accepted for ?size=
+func SizeNames() []string {
+	names := make([]string, 0, len(Sizes))
+	for _, s := range Sizes {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// Rendition is one encoded size of a processed photo
+type Rendition struct {
+	Size string
+	Data []byte
+	Info Info
+}
+
+// Process decodes an upload, checks that its bytes really are declaredMIME, applies the EXIF
+// orientation and re-encodes every size. Re-encoding drops all metadata, including GPS
+// coordinates. The output keeps the input format: PNG for PNG (transparency), JPEG otherwise.
+func Process(data []byte, declaredMIME string) (mimeType string, renditions []Rendition, err error) {
+	sniffed := http.DetectContentType(data)
+	if sniffed != "image/jpeg" && sniffed != "image/png" {
+		return "", nil, ErrUnsupported
+	}
+	if sniffed != declaredMIME {
+		return "", nil, ErrMismatch
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, err
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return "", nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, err
+	}
+
+	img := toRGBA(src)
+	if sniffed == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	// Each size is scaled from the previous one, which is much cheaper than from the original
+	for _, size := range Sizes {
+		img = fit(img, size.MaxSide)
+
+		var buf bytes.Buffer
+		if sniffed == "image/png" {
+			err = png.Encode(&buf, img)
+		} else {
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		out := buf.Bytes()
+		renditions = append(renditions, Rendition{
+			Size: size.Name,
+			Data: out,
+			Info: Info{
+				Width:    img.Bounds().Dx(),
+				Height:   img.Bounds().Dy(),
+				Size:     int64(len(out)),
+				Checksum: Checksum(out),
+			},
+		})
+	}
+	return sniffed, renditions, nil
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// fit scales img down so its longest side is at most maxSide
+func fit(img *image.RGBA, maxSide int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	if w >= h {
+		h = max(1, h*maxSide/w)
+		w = maxSide
+	} else {
+		w = max(1, w*maxSide/h)
+		h = maxSide
+	}
+	return downscale(img, w, h)
+}
+
+// downscale averages every source pixel that falls into each destination pixel (a box filter).
+// Pixels are premultiplied, so transparent edges don't bleed dark.
+func downscale(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+
+			d := dst.Pix[y*dst.Stride+x*4 : y*dst.Stride+x*4+4]
+			d[0], d[1], d[2], d[3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
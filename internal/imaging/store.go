@@ -0,0 +1,42 @@
+package imaging
+
+import (
+	"context"
+
+	"ships-backend/internal/blob"
+	"ships-backend/internal/models"
+)
+
+// StoreRenditions writes every rendition to the blob store and records them on photo, whose ID
+// and UserID must be set. If a write fails, the ones already written are removed again.
+func StoreRenditions(ctx context.Context, blobs blob.Store, photo *models.UserPhoto, mimeType string, renditions []Rendition) error {
+	var written []string
+	for _, r := range renditions {
+		key := models.PhotoKey(photo.UserID, photo.ID, r.Size)
+		if err := blobs.Put(ctx, key, r.Data, mimeType); err != nil {
+			for _, k := range written {
+				_ = blobs.Delete(ctx, k)
+			}
+			return err
+		}
+		written = append(written, key)
+	}
+
+	photo.MimeType = mimeType
+	photo.Variants = map[string]models.PhotoVariant{}
+	for _, r := range renditions {
+		v := models.PhotoVariant{
+			Key:      models.PhotoKey(photo.UserID, photo.ID, r.Size),
+			Size:     r.Info.Size,
+			Width:    r.Info.Width,
+			Height:   r.Info.Height,
+			Checksum: r.Info.Checksum,
+		}
+		if r.Size == models.FullPhotoSize {
+			photo.Key, photo.Size, photo.Width, photo.Height, photo.Checksum = v.Key, v.Size, v.Width, v.Height, v.Checksum
+			continue
+		}
+		photo.Variants[r.Size] = v
+	}
+	return nil
+}
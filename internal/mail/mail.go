@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is a rendered email ready to hand to a Mailer
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers rendered messages. Drivers: SMTPMailer, OutboxMailer and MemoryMailer.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// buildMIME encodes a message as multipart/alternative so clients can pick text or HTML
+func buildMIME(from string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + from,
+		"To: " + msg.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageID(from),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + writer.Boundary(),
+	}
+	var out bytes.Buffer
+	out.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, p := range parts {
+		if p.body == "" {
+			continue
+		}
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write([]byte(strings.ReplaceAll(p.body, "\n", "\r\n"))); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
+
+func messageID(from string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at != -1 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
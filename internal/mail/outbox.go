@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OutboxMailer writes each message as an .eml file in Dir instead of sending it. Meant for local development.
+type OutboxMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *OutboxMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	raw, err := buildMIME(m.From, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), sanitizeFileName(msg.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), raw, 0o644)
+}
+
+func sanitizeFileName(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
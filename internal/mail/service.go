@@ -0,0 +1,82 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Service is what handlers use to send email: a Mailer plus the templates to render
+type Service struct {
+	Mailer Mailer
+	*Templates
+}
+
+func NewService(mailer Mailer, templates *Templates) *Service {
+	return &Service{Mailer: mailer, Templates: templates}
+}
+
+// NewServiceFromEnv picks a driver from MAIL_DRIVER (smtp, outbox or memory).
+// PUBLIC_BASE_URL is the origin used in links inside emails.
+func NewServiceFromEnv() (*Service, error) {
+	from := getEnv("MAIL_FROM", "no-reply@localhost")
+
+	var mailer Mailer
+	switch driver := getEnv("MAIL_DRIVER", "outbox"); driver {
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("MAIL_DRIVER=smtp requires SMTP_HOST")
+		}
+		mailer = &SMTPMailer{
+			Host:     host,
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "outbox":
+		mailer = &OutboxMailer{Dir: getEnv("MAIL_OUTBOX_DIR", "outbox"), From: from}
+	case "memory":
+		mailer = NewMemoryMailer()
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", driver)
+	}
+
+	templates, err := NewTemplates(getEnv("PUBLIC_BASE_URL", "http://localhost:8080"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewService(mailer, templates), nil
+}
+
+// SendTemplate renders and sends a templated email
+func (s *Service) SendTemplate(ctx context.Context, to, name string, data map[string]any) error {
+	msg, err := s.Render(name, to, data)
+	if err != nil {
+		return err
+	}
+	return s.Mailer.Send(ctx, msg)
+}
+
+// SendAsync sends in the background and logs failures; for emails that must not block a response
+func (s *Service) SendAsync(to, name string, data map[string]any) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := s.SendTemplate(ctx, to, name, data); err != nil {
+			log.Printf("Failed to send %s email to %s: %v", name, to, err)
+		}
+	}()
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"context"
+	"net/smtp"
+)
+
+// SMTPMailer sends through a real SMTP relay using PLAIN auth
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	raw, err := buildMIME(m.From, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{msg.To}, raw)
+}
@@ -0,0 +1,102 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// Templates renders the paired <name>.txt / <name>.html templates in templates/.
+// The text template must define a "subject" block.
+type Templates struct {
+	baseURL string
+	text    map[string]*texttemplate.Template
+	html    map[string]*htmltemplate.Template
+}
+
+func NewTemplates(baseURL string) (*Templates, error) {
+	t := &Templates{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		text:    map[string]*texttemplate.Template{},
+		html:    map[string]*htmltemplate.Template{},
+	}
+
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		path := "templates/" + name
+		switch {
+		case strings.HasSuffix(name, ".txt"):
+			tmpl, err := texttemplate.ParseFS(templateFS, path)
+			if err != nil {
+				return nil, err
+			}
+			t.text[strings.TrimSuffix(name, ".txt")] = tmpl
+		case strings.HasSuffix(name, ".html"):
+			tmpl, err := htmltemplate.ParseFS(templateFS, path)
+			if err != nil {
+				return nil, err
+			}
+			t.html[strings.TrimSuffix(name, ".html")] = tmpl
+		}
+	}
+
+	return t, nil
+}
+
+// Link builds an absolute URL on the configured public base URL
+func (t *Templates) Link(path string, query url.Values) string {
+	link := t.baseURL + path
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
+
+// Render executes the named template pair. BaseURL is always available to templates.
+func (t *Templates) Render(name, to string, data map[string]any) (Message, error) {
+	textTmpl, ok := t.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("mail template %q not found", name)
+	}
+
+	vars := map[string]any{"BaseURL": t.baseURL}
+	for k, v := range data {
+		vars[k] = v
+	}
+
+	var subject, text bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", vars); err != nil {
+		return Message{}, err
+	}
+	if err := textTmpl.Execute(&text, vars); err != nil {
+		return Message{}, err
+	}
+
+	msg := Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}
+
+	if htmlTmpl, ok := t.html[name]; ok {
+		var html bytes.Buffer
+		if err := htmlTmpl.Execute(&html, vars); err != nil {
+			return Message{}, err
+		}
+		msg.HTML = html.String()
+	}
+
+	return msg, nil
+}
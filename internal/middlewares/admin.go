@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"ships-backend/internal/models"
+)
+
+// IsAdmin reports whether userID has the admin role
+func IsAdmin(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (bool, error) {
+	n, err := db.Collection("users").CountDocuments(ctx, bson.M{"_id": userID, "role": models.RoleAdmin})
+	return n > 0, err
+}
+
+// RequireAdmin returns 403 for anyone without the admin role. The role is read on every request,
+// so revoking it takes effect right away. It must run after AuthMiddleware.
+func RequireAdmin(db *mongo.Database) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value(UserIDKey).(string)
+			objID, err := primitive.ObjectIDFromHex(userID)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			defer cancel()
+
+			admin, err := IsAdmin(ctx, db, objID)
+			if err != nil {
+				http.Error(w, "Could not check permissions", http.StatusInternalServerError)
+				return
+			}
+			if !admin {
+				http.Error(w, "Admins only", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -5,42 +5,66 @@ import (
 	"net/http"
 	"ships-backend/internal/utils"
 	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type contextKey string
 
 const UserIDKey contextKey = "userID"
+const SessionIDKey contextKey = "sessionID"
+
+// AuthMiddleware validates the bearer JWT and rejects it when the session it belongs to
+// has been revoked or has expired
+func AuthMiddleware(db *mongo.Database) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := utils.ValidateJWT(parts[1])
+			if err != nil {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			userObjID, err := primitive.ObjectIDFromHex(claims.UserID)
+			sessionObjID, err2 := primitive.ObjectIDFromHex(claims.SessionID)
+			if err != nil || err2 != nil {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			defer cancel()
+
+			count, err := db.Collection("auth_sessions").CountDocuments(ctx, bson.M{
+				"_id":       sessionObjID,
+				"userId":    userObjID,
+				"revokedAt": bson.M{"$exists": false},
+				"expiresAt": bson.M{"$gt": time.Now()},
+			})
+			if err != nil || count == 0 {
+				http.Error(w, "Session revoked or expired", http.StatusUnauthorized)
+				return
+			}
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
-			return
-		}
-
-		userID, err := utils.ValidateJWT(parts[1])
-		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
-			return
-		}
-
-		// wrap protected routes with an extra check
-		// After extracting user ID from JWT
-		/*err := db.Collection("users").FindOne(ctx, bson.M{"_id": objID, "emailVerified": true}).Decode(&user)
-		if err != nil {
-			http.Error(w, "Email not verified", http.StatusForbidden)
-			return
-		}*/
-
-		// Inject userID into context
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			// Inject userID and sessionID into context
+			reqCtx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			reqCtx = context.WithValue(reqCtx, SessionIDKey, claims.SessionID)
+			next.ServeHTTP(w, r.WithContext(reqCtx))
+		})
+	}
 }
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RequireVerifiedEmail returns 403 for accounts that haven't verified their email.
+// It must run after AuthMiddleware and is opt-in per route.
+func RequireVerifiedEmail(db *mongo.Database) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value(UserIDKey).(string)
+			objID, err := primitive.ObjectIDFromHex(userID)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			defer cancel()
+
+			count, err := db.Collection("users").CountDocuments(ctx, bson.M{"_id": objID, "emailVerified": true})
+			if err != nil {
+				http.Error(w, "Could not check email verification", http.StatusInternalServerError)
+				return
+			}
+			if count == 0 {
+				http.Error(w, "Email not verified", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
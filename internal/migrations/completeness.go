@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"ships-backend/internal/completeness"
+	"ships-backend/internal/models"
+)
+
+// backfillCompleteness scores every existing user. Discovery filters on the stored score, so
+// accounts without one would never be shown.
+func backfillCompleteness(ctx context.Context, d Deps) error {
+	cursor, err := d.DB.Collection("users").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var scored int
+	for cursor.Next(ctx) {
+		var u models.User
+		if err := cursor.Decode(&u); err != nil {
+			return err
+		}
+		if _, err := completeness.Store(ctx, d.DB, u); err != nil {
+			return err
+		}
+		scored++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	log.Printf("completeness: %d users scored", scored)
+	return nil
+}
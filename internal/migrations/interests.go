@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"ships-backend/internal/catalog"
+	"ships-backend/internal/models"
+	"ships-backend/internal/validation"
+)
+
+// interestsToCatalog rewrites interests and dealbreakers as catalog IDs. Values that match no name
+// or alias are moved to legacyInterests so nothing the user typed is lost.
+func interestsToCatalog(ctx context.Context, d Deps) error {
+	users := d.DB.Collection("users")
+	cursor, err := users.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"interests.0": bson.M{"$exists": true}},
+		bson.M{"preferences.dealbreakers.0": bson.M{"$exists": true}},
+	}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var updated, unmapped int
+	for cursor.Next(ctx) {
+		var u models.User
+		if err := cursor.Decode(&u); err != nil {
+			return err
+		}
+
+		interests, leftover := mapInterests(u.Interests)
+		set := bson.M{"interests": interests}
+		if u.Preferences != nil {
+			dealbreakers, _ := mapInterests(u.Preferences.Dealbreakers)
+			set["preferences.dealbreakers"] = dealbreakers
+		}
+		update := bson.M{"$set": set}
+		if len(leftover) > 0 {
+			update["$addToSet"] = bson.M{"legacyInterests": bson.M{"$each": leftover}}
+			unmapped += len(leftover)
+		}
+
+		if _, err := users.UpdateByID(ctx, u.ID, update); err != nil {
+			return err
+		}
+		updated++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	log.Printf("interests: %d users rewritten, %d values without a catalog match", updated, unmapped)
+	return nil
+}
+
+// mapInterests returns the distinct catalog IDs for values, capped at MaxInterests, and the values
+// that have no match
+func mapInterests(values []string) (ids []string, leftover []string) {
+	ids = []string{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		id, ok := catalog.MatchInterest(value)
+		if !ok {
+			leftover = append(leftover, value)
+			continue
+		}
+		if seen[id] || len(ids) == validation.MaxInterests {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, leftover
+}
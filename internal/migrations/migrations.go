@@ -0,0 +1,124 @@
+// Package migrations runs one-off data changes. Each migration runs once per database; applied IDs
+// are recorded in the "migrations" collection.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"ships-backend/internal/blob"
+)
+
+// Deps is what a migration may touch
+type Deps struct {
+	DB    *mongo.Database
+	Blobs blob.Store
+}
+
+type Migration struct {
+	ID          string // never renamed once shipped, it's how we know it ran
+	Description string
+	Up          func(ctx context.Context, d Deps) error
+}
+
+// All migrations, in the order they run
+var All = []Migration{
+	{
+		ID:          "0001_interests_to_catalog",
+		Description: "map free-text interests and dealbreakers onto catalog interest IDs",
+		Up:          interestsToCatalog,
+	},
+	{
+		ID:          "0002_profile_completeness",
+		Description: "store a completeness score on every user",
+		Up:          backfillCompleteness,
+	},
+	{
+		ID:          "0003_photos_to_blob_store",
+		Description: "move embedded photo bytes from user_photos into the blob store",
+		Up:          photosToBlobStore,
+	},
+	{
+		ID:          "0004_photo_renditions",
+		Description: "re-encode stored photos without metadata and add card and thumb sizes",
+		Up:          photoRenditions,
+	},
+	{
+		ID:          "0005_photo_moderation_status",
+		Description: "approve photos uploaded before moderation and rescore their owners",
+		Up:          approveExistingPhotos,
+	},
+}
+
+type record struct {
+	ID          string    `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// Status is one migration and when it was applied, if ever
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// List returns every migration with its applied time
+func List(ctx context.Context, db *mongo.Database) ([]Status, error) {
+	applied, err := appliedAt(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(All))
+	for _, m := range All {
+		s := Status{Migration: m}
+		if t, ok := applied[m.ID]; ok {
+			s.AppliedAt = &t
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// Run applies pending migrations in order and stops at the first failure
+func Run(ctx context.Context, d Deps) error {
+	db := d.DB
+	applied, err := appliedAt(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, m := range All {
+		if _, ok := applied[m.ID]; ok {
+			continue
+		}
+		log.Printf("migration %s: %s", m.ID, m.Description)
+		if err := m.Up(ctx, d); err != nil {
+			return fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+		_, err := db.Collection("migrations").InsertOne(ctx, record{ID: m.ID, Description: m.Description, AppliedAt: time.Now()})
+		if err != nil {
+			return fmt.Errorf("recording migration %s: %w", m.ID, err)
+		}
+	}
+	return nil
+}
+
+func appliedAt(ctx context.Context, db *mongo.Database) (map[string]time.Time, error) {
+	cursor, err := db.Collection("migrations").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[string]time.Time, len(records))
+	for _, r := range records {
+		applied[r.ID] = r.AppliedAt
+	}
+	return applied, nil
+}
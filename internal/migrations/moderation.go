@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"ships-backend/internal/completeness"
+	"ships-backend/internal/models"
+)
+
+// approveExistingPhotos marks photos from before moderation as approved; only approved photos are
+// shown or scored. Owners are rescored, since 0002 may have counted none of their photos.
+func approveExistingPhotos(ctx context.Context, d Deps) error {
+	photos := d.DB.Collection("user_photos")
+	filter := bson.M{"status": bson.M{"$exists": false}}
+
+	owners, err := photos.Distinct(ctx, "userId", filter)
+	if err != nil {
+		return err
+	}
+	res, err := photos.UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"status":             models.PhotoApproved,
+		"moderation.verdict": "legacy",
+	}})
+	if err != nil {
+		return err
+	}
+
+	var rescored int
+	for _, owner := range owners {
+		id, ok := owner.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		if _, err := completeness.Recompute(ctx, d.DB, id); err != nil {
+			log.Printf("moderation: user %s not rescored: %v", id.Hex(), err)
+			continue
+		}
+		rescored++
+	}
+
+	log.Printf("moderation: %d photos approved, %d users rescored", res.ModifiedCount, rescored)
+	return nil
+}
@@ -0,0 +1,177 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/completeness"
+	"ships-backend/internal/imaging"
+	"ships-backend/internal/models"
+)
+
+// photosToBlobStore copies each embedded image into the blob store, records key, size,
+// dimensions and checksum, then drops the bytes from the document. A photo is only touched once
+// its blob is written, so an interrupted run can simply be started again.
+func photosToBlobStore(ctx context.Context, d Deps) error {
+	photos := d.DB.Collection("user_photos")
+	cursor, err := photos.Find(ctx, bson.M{"data": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var moved int
+	for cursor.Next(ctx) {
+		var legacy struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			UserID   primitive.ObjectID `bson:"userId"`
+			Data     []byte             `bson:"data"`
+			MimeType string             `bson:"mimeType"`
+		}
+		if err := cursor.Decode(&legacy); err != nil {
+			return err
+		}
+
+		info, err := imaging.Describe(legacy.Data)
+		if err != nil {
+			// Still moved, so the document shrinks; it just has no dimensions
+			log.Printf("photo %s: unreadable image header: %v", legacy.ID.Hex(), err)
+			info = imaging.Info{Size: int64(len(legacy.Data)), Checksum: imaging.Checksum(legacy.Data)}
+		}
+		key := models.PhotoKey(legacy.UserID, legacy.ID, models.FullPhotoSize)
+		if err := d.Blobs.Put(ctx, key, legacy.Data, legacy.MimeType); err != nil {
+			return fmt.Errorf("photo %s: %w", legacy.ID.Hex(), err)
+		}
+
+		_, err = photos.UpdateByID(ctx, legacy.ID, bson.M{
+			"$set": bson.M{
+				"key":      key,
+				"size":     info.Size,
+				"width":    info.Width,
+				"height":   info.Height,
+				"checksum": info.Checksum,
+			},
+			"$unset": bson.M{"data": ""},
+		})
+		if err != nil {
+			return err
+		}
+		moved++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	log.Printf("photos: %d moved to the blob store", moved)
+	return nil
+}
+
+// photoRenditions runs photos uploaded before the image pipeline through it: the full size is
+// replaced by a re-encoded copy without EXIF and the smaller sizes are added. Photos that can't
+// be decoded are deleted, since their bytes may still carry a location; the owner's other photos
+// are renumbered and the owner rescored.
+func photoRenditions(ctx context.Context, d Deps) error {
+	photos := d.DB.Collection("user_photos")
+	cursor, err := photos.Find(ctx, bson.M{"variants": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var processed, removed int
+	owners := map[primitive.ObjectID]bool{}
+	for cursor.Next(ctx) {
+		var photo models.UserPhoto
+		if err := cursor.Decode(&photo); err != nil {
+			return err
+		}
+
+		r, err := d.Blobs.Get(ctx, photo.Key)
+		if err != nil {
+			return fmt.Errorf("photo %s: %w", photo.ID.Hex(), err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("photo %s: %w", photo.ID.Hex(), err)
+		}
+
+		mimeType, renditions, err := imaging.Process(data, http.DetectContentType(data))
+		if err != nil {
+			log.Printf("photo %s of user %s: deleted, could not be re-encoded: %v", photo.ID.Hex(), photo.UserID.Hex(), err)
+			// The record first, so a leftover blob is never served
+			if _, err := photos.DeleteOne(ctx, bson.M{"_id": photo.ID}); err != nil {
+				return err
+			}
+			if err := d.Blobs.Delete(ctx, photo.Key); err != nil {
+				return fmt.Errorf("photo %s: %w", photo.ID.Hex(), err)
+			}
+			owners[photo.UserID] = true
+			removed++
+			continue
+		}
+		if err := imaging.StoreRenditions(ctx, d.Blobs, &photo, mimeType, renditions); err != nil {
+			return fmt.Errorf("photo %s: %w", photo.ID.Hex(), err)
+		}
+
+		_, err = photos.UpdateByID(ctx, photo.ID, bson.M{"$set": bson.M{
+			"key":      photo.Key,
+			"mimeType": photo.MimeType,
+			"size":     photo.Size,
+			"width":    photo.Width,
+			"height":   photo.Height,
+			"checksum": photo.Checksum,
+			"variants": photo.Variants,
+		}})
+		if err != nil {
+			return err
+		}
+		processed++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for owner := range owners {
+		if err := renumberPhotos(ctx, d.DB, owner); err != nil {
+			return err
+		}
+		if _, err := completeness.Recompute(ctx, d.DB, owner); err != nil {
+			log.Printf("photos: user %s not rescored: %v", owner.Hex(), err)
+		}
+	}
+
+	log.Printf("photos: %d re-encoded with renditions, %d deleted as unreadable", processed, removed)
+	return nil
+}
+
+// renumberPhotos closes the gaps in a user's photo order left by deleted photos
+func renumberPhotos(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	photos := db.Collection("user_photos")
+	cursor, err := photos.Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.M{"order": 1}))
+	if err != nil {
+		return err
+	}
+	var remaining []models.UserPhoto
+	if err := cursor.All(ctx, &remaining); err != nil {
+		return err
+	}
+
+	for i, p := range remaining {
+		if p.Order == i {
+			continue
+		}
+		if _, err := photos.UpdateByID(ctx, p.ID, bson.M{"$set": bson.M{"order": i}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,12 +5,15 @@ import (
 	"time"
 )
 
+// AuthSession is one signed-in device. Its ID is carried in the JWT "sid" claim and doubles as
+// the refresh token family, so revoking the session also kills its refresh tokens.
 type AuthSession struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
-	Token     string             `bson:"token" json:"token"`
-	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
-	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
-	UserAgent string             `bson:"userAgent" json:"userAgent"`
-	IP        string             `bson:"ip" json:"ip"`
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
+	ExpiresAt  time.Time          `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	LastSeenAt time.Time          `bson:"lastSeenAt" json:"lastSeenAt"`
+	RevokedAt  *time.Time         `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+	UserAgent  string             `bson:"userAgent" json:"userAgent"`
+	IP         string             `bson:"ip" json:"ip"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Block hides two users from each other in both directions, whoever created it
+type Block struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Blocker   primitive.ObjectID `bson:"blocker" json:"-"`
+	Blocked   primitive.ObjectID `bson:"blocked" json:"userId"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -12,5 +12,5 @@ type CrossedPath struct {
 	Timestamp    time.Time          `bson:"timestamp"`
 	Location     Location           `bson:"location"` // where they crossed
 	TimesCrossed int                `bson:"timesCrossed"`
-	OtherUser    *User              `bson:"-" json:"otherUser,omitempty"`
+	OtherUser    *PublicCard        `bson:"-" json:"otherUser,omitempty"`
 }
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type ExportStatus string
+
+const (
+	ExportPending ExportStatus = "pending"
+	ExportReady   ExportStatus = "ready"
+	ExportFailed  ExportStatus = "failed"
+)
+
+// DataExport is a personal data archive built in the background and downloaded through a short-lived token
+type DataExport struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID            primitive.ObjectID `bson:"userId" json:"-"`
+	Status            ExportStatus       `bson:"status" json:"status"`
+	Error             string             `bson:"error,omitempty" json:"-"`
+	File              string             `bson:"file,omitempty" json:"-"` // archive path under EXPORT_DIR
+	Size              int64              `bson:"size,omitempty" json:"size,omitempty"`
+	DownloadTokenHash string             `bson:"downloadTokenHash,omitempty" json:"-"`
+	DownloadExpiresAt time.Time          `bson:"downloadExpiresAt,omitempty" json:"-"`
+	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
+	CompletedAt       *time.Time         `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+	ExpiresAt         time.Time          `bson:"expiresAt" json:"expiresAt"` // archive and record are removed after this
+}
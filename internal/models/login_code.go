@@ -0,0 +1,20 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// LoginCode is a passwordless sign-in: a 6-digit code and a magic link token, both single-use
+type LoginCode struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	UserID        primitive.ObjectID `bson:"userId"`
+	Email         string             `bson:"email"`
+	CodeHash      string             `bson:"codeHash"`
+	LinkTokenHash string             `bson:"linkTokenHash"`
+	Attempts      int                `bson:"attempts"`
+	IP            string             `bson:"ip"`
+	CreatedAt     time.Time          `bson:"createdAt"`
+	ExpiresAt     time.Time          `bson:"expiresAt"`
+	UsedAt        *time.Time         `bson:"usedAt,omitempty"`
+}
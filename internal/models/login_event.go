@@ -0,0 +1,16 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// LoginEvent records a successful sign-in; kept longer than the session itself for the user's login history
+type LoginEvent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID    primitive.ObjectID `bson:"userId" json:"-"`
+	SessionID primitive.ObjectID `bson:"sessionId" json:"sessionId"`
+	IP        string             `bson:"ip" json:"ip"`
+	UserAgent string             `bson:"userAgent" json:"userAgent"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -10,6 +10,7 @@ type Match struct {
 	User1     primitive.ObjectID `bson:"user1"`
 	User2     primitive.ObjectID `bson:"user2"`
 	CreatedAt time.Time          `bson:"createdAt"`
+	ClosedAt  *time.Time         `bson:"closedAt,omitempty"` // set when one side deletes their account or blocks the other
 }
 
 func NewMatch(userA, userB primitive.ObjectID) Match {
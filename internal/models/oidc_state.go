@@ -0,0 +1,17 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// OIDCState remembers an in-flight social login between the redirect out and the callback
+type OIDCState struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	Provider     string             `bson:"provider"`
+	StateHash    string             `bson:"stateHash"`
+	Nonce        string             `bson:"nonce"`
+	CodeVerifier string             `bson:"codeVerifier"`
+	CreatedAt    time.Time          `bson:"createdAt"`
+	ExpiresAt    time.Time          `bson:"expiresAt"`
+}
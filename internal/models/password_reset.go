@@ -0,0 +1,16 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type PasswordReset struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"userId"`
+	TokenHash string             `bson:"tokenHash"`
+	IP        string             `bson:"ip"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+	UsedAt    *time.Time         `bson:"usedAt,omitempty"`
+}
@@ -5,13 +5,93 @@ import (
 	"time"
 )
 
+// FullPhotoSize is the largest rendition, the one UserPhoto.Key points at
+const FullPhotoSize = "full"
+
+// Photo moderation states; other users only ever see approved photos
+const (
+	PhotoPending  = "pending" // waiting for a moderator
+	PhotoApproved = "approved"
+	PhotoRejected = "rejected"
+)
+
+// PhotoVariant is one stored rendition of a photo
+type PhotoVariant struct {
+	Key      string `bson:"key"`
+	Size     int64  `bson:"size"` // bytes
+	Width    int    `bson:"width"`
+	Height   int    `bson:"height"`
+	Checksum string `bson:"checksum"` // hex sha256 of the stored bytes
+}
+
+// UserPhoto describes a photo; the image itself lives in the blob store under Key
 type UserPhoto struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty"`
 	UserID    primitive.ObjectID `bson:"userId"`
-	Data      []byte             `bson:"data"`     // raw image bytes
-	MimeType  string             `bson:"mimeType"` // e.g. image/jpeg
+	Key       string             `bson:"key"`      // blob store key of the full size, see PhotoKey
+	MimeType  string             `bson:"mimeType"` // e.g. image/jpeg, same for every size
+	Size      int64              `bson:"size"`     // bytes
+	Width     int                `bson:"width"`
+	Height    int                `bson:"height"`
+	Checksum  string             `bson:"checksum"` // hex sha256 of the stored bytes
 	CreatedAt time.Time          `bson:"createdAt"`
 	Order     int                `bson:"order"`
+
+	// Smaller renditions by size name ("card", "thumb")
+	Variants map[string]PhotoVariant `bson:"variants,omitempty"`
+
+	Status     string          `bson:"status"` // PhotoPending, PhotoApproved or PhotoRejected
+	Moderation PhotoModeration `bson:"moderation"`
+}
+
+// PhotoModeration records how a photo got its status
+type PhotoModeration struct {
+	Verdict    string              `bson:"verdict"`          // what the classifier said, see the moderation package
+	Labels     []string            `bson:"labels,omitempty"` // why, shown to moderators
+	Reason     string              `bson:"reason,omitempty"` // rejection reason ID
+	Note       string              `bson:"note,omitempty"`   // moderator's words to the owner
+	ReviewedBy *primitive.ObjectID `bson:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time          `bson:"reviewedAt,omitempty"`
+}
+
+// PhotoKey is where a photo's bytes are kept in the blob store. Sizes other than the full one
+// get a suffix.
+func PhotoKey(userID, photoID primitive.ObjectID, size string) string {
+	key := "photos/" + userID.Hex() + "/" + photoID.Hex()
+	if size != FullPhotoSize {
+		key += "-" + size
+	}
+	return key
+}
+
+// Variant returns the requested size, falling back to the full one when it doesn't exist
+func (p *UserPhoto) Variant(size string) PhotoVariant {
+	if v, ok := p.Variants[size]; ok {
+		return v
+	}
+	return PhotoVariant{Key: p.Key, Size: p.Size, Width: p.Width, Height: p.Height, Checksum: p.Checksum}
+}
+
+// BlobKeys lists every stored rendition
+func (p *UserPhoto) BlobKeys() []string {
+	keys := []string{p.Key}
+	for _, v := range p.Variants {
+		keys = append(keys, v.Key)
+	}
+	return keys
+}
+
+// PhotoRef is how photos appear in API responses: where to fetch each size, never the blob key
+type PhotoRef struct {
+	ID     primitive.ObjectID `json:"id"`
+	Order  int                `json:"order"`
+	Width  int                `json:"width"`
+	Height int                `json:"height"`
+	URLs   map[string]string  `json:"urls"` // size name → versioned URL
+
+	// Only on the owner's own photos
+	Status       string `json:"status,omitempty"`
+	RejectReason string `json:"rejectReason,omitempty"`
 }
 
 type UpdatePhotoOrderRequest struct {
@@ -0,0 +1,47 @@
+package models
+
+// Defaults for users who never saved their discovery preferences
+const (
+	DefaultMaxDistanceKm = 5
+	DefaultMinAge        = 18
+	DefaultMaxAge        = 99
+)
+
+// DiscoveryPreferences decide who a user is shown, and who they are shown to: matching goes both ways
+type DiscoveryPreferences struct {
+	Genders       []string `bson:"genders" json:"genders"` // empty means everyone
+	MinAge        int      `bson:"minAge" json:"minAge"`
+	MaxAge        int      `bson:"maxAge" json:"maxAge"`
+	MaxDistanceKm float64  `bson:"maxDistanceKm" json:"maxDistanceKm"`
+	Dealbreakers  []string `bson:"dealbreakers" json:"dealbreakers"` // others must share at least one of these interests
+
+	// Filter name (see catalog.Filters) → accepted values, e.g. {"smoking": ["never"]}
+	Filters map[string][]string `bson:"filters,omitempty" json:"filters"`
+}
+
+// WithDefaults fills in whatever the user has not set
+func (p *DiscoveryPreferences) WithDefaults() DiscoveryPreferences {
+	out := DiscoveryPreferences{}
+	if p != nil {
+		out = *p
+	}
+	if out.Genders == nil {
+		out.Genders = []string{}
+	}
+	if out.Dealbreakers == nil {
+		out.Dealbreakers = []string{}
+	}
+	if out.Filters == nil {
+		out.Filters = map[string][]string{}
+	}
+	if out.MinAge == 0 {
+		out.MinAge = DefaultMinAge
+	}
+	if out.MaxAge == 0 {
+		out.MaxAge = DefaultMaxAge
+	}
+	if out.MaxDistanceKm == 0 {
+		out.MaxDistanceKm = DefaultMaxDistanceKm
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package models
+
+import "ships-backend/internal/catalog"
+
+// Lifestyle answers; values come from the catalog
+type Lifestyle struct {
+	Drinking string `bson:"drinking,omitempty" json:"drinking,omitempty"`
+	Smoking  string `bson:"smoking,omitempty" json:"smoking,omitempty"`
+	Kids     string `bson:"kids,omitempty" json:"kids,omitempty"`
+}
+
+// ProfilePrompt is the user's answer to one catalog prompt
+type ProfilePrompt struct {
+	PromptID string `bson:"promptId" json:"promptId"`
+	Answer   string `bson:"answer" json:"answer"`
+}
+
+// ProfileDetails are the optional profile fields. They are stored inline on the user document
+// and each can be hidden from non-matches or everyone through Visibility.
+type ProfileDetails struct {
+	HeightCm         int             `bson:"heightCm,omitempty" json:"heightCm,omitempty"`
+	Occupation       string          `bson:"occupation,omitempty" json:"occupation,omitempty"`
+	Education        string          `bson:"education,omitempty" json:"education,omitempty"`
+	Languages        []string        `bson:"languages,omitempty" json:"languages,omitempty"` // ISO 639-1 codes
+	RelationshipGoal string          `bson:"relationshipGoal,omitempty" json:"relationshipGoal,omitempty"`
+	Lifestyle        *Lifestyle      `bson:"lifestyle,omitempty" json:"lifestyle,omitempty"`
+	Prompts          []ProfilePrompt `bson:"prompts,omitempty" json:"prompts,omitempty"`
+
+	// Field name (as in JSON) → catalog.Visible*; missing means everyone
+	Visibility map[string]string `bson:"visibility,omitempty" json:"visibility,omitempty"`
+}
+
+// DetailFields are the JSON names of the fields Visibility can be set for
+var DetailFields = []string{"heightCm", "occupation", "education", "languages", "relationshipGoal", "lifestyle", "prompts"}
+
+// Redact clears the fields the viewer may not see and drops the visibility settings themselves
+func (d *ProfileDetails) Redact(viewerIsMatch bool) {
+	hidden := func(field string) bool {
+		switch d.Visibility[field] {
+		case catalog.VisiblePrivate:
+			return true
+		case catalog.VisibleMatches:
+			return !viewerIsMatch
+		}
+		return false
+	}
+
+	if hidden("heightCm") {
+		d.HeightCm = 0
+	}
+	if hidden("occupation") {
+		d.Occupation = ""
+	}
+	if hidden("education") {
+		d.Education = ""
+	}
+	if hidden("languages") {
+		d.Languages = nil
+	}
+	if hidden("relationshipGoal") {
+		d.RelationshipGoal = ""
+	}
+	if hidden("lifestyle") {
+		d.Lifestyle = nil
+	}
+	if hidden("prompts") {
+		d.Prompts = nil
+	}
+	d.Visibility = nil
+}
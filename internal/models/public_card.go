@@ -0,0 +1,19 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// PublicCard is what other users get to see of a profile: no email, no exact location, no
+// account state. Age and a rounded distance stand in for the birth date and coordinates.
+type PublicCard struct {
+	ID         primitive.ObjectID `json:"id"`
+	Name       string             `json:"name"`
+	Age        int                `json:"age"`
+	Gender     string             `json:"gender,omitempty"`
+	Bio        string             `json:"bio,omitempty"`
+	Interests  []string           `json:"interests"`
+	DistanceKm *int               `json:"distanceKm,omitempty"` // whole km, rounded up; missing without both locations
+	Matched    bool               `json:"matched"`
+	Photos     []PhotoRef         `json:"photos"`
+
+	ProfileDetails // already redacted for the viewer
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// RefreshToken is a single link in a rotation chain. Every token minted from
+// the same login shares a FamilyID so a replayed token can revoke the chain.
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"userId"`
+	FamilyID  primitive.ObjectID `bson:"familyId"`
+	TokenHash string             `bson:"tokenHash"` // sha256 of the token, never the token itself
+	UserAgent string             `bson:"userAgent"`
+	IP        string             `bson:"ip"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+	UsedAt    *time.Time         `bson:"usedAt,omitempty"`
+	RevokedAt *time.Time         `bson:"revokedAt,omitempty"`
+}
@@ -6,11 +6,11 @@ import (
 )
 
 type ProfileUpdateRequest struct {
-	Name      string   `json:"name"`
-	Bio       string   `json:"bio"`
-	Interests []string `json:"interests"`
-	Gender    string   `json:"gender"`
-	Location  Location `json:"location"`
+	Name      string    `json:"name"`
+	Bio       string    `json:"bio"`
+	Interests []string  `json:"interests"`
+	Gender    string    `json:"gender"`
+	Location  *Location `json:"location"` // optional, the stored location is kept when omitted
 }
 
 type Location struct {
@@ -18,6 +18,13 @@ type Location struct {
 	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
 }
 
+// ExternalIdentity links a user to an account at an OpenID Connect provider
+type ExternalIdentity struct {
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"-"`
+	LinkedAt time.Time `bson:"linkedAt" json:"linkedAt"`
+}
+
 type User struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name          string             `bson:"name" json:"name"`
@@ -25,20 +32,74 @@ type User struct {
 	Password      string             `bson:"password,omitempty" json:"-"`
 	Bio           string             `bson:"bio,omitempty" json:"bio,omitempty"`
 	Gender        string             `bson:"gender" json:"gender"`       // e.g., "male", "female", "non-binary"
-	Interests     []string           `bson:"interests" json:"interests"` // e.g., ["anime", "gaming", "rock"]
-	Birth         time.Time
-	Location      Location  `bson:"location" json:"location"` // For geo queries
-	EmailVerified bool      `bson:"emailVerified" json:"emailVerified"`
-	VerifyToken   string    `bson:"verifyToken,omitempty" json:"-"`
-	CreatedAt     time.Time `bson:"createdAt" json:"createdAt"`
-	UpdatedAt     time.Time `bson:"updatedAt" json:"updatedAt"`
+	Interests     []string           `bson:"interests" json:"interests"` // catalog IDs, e.g., ["anime", "video_games", "rock"]
+	Birth         time.Time          `bson:"birth" json:"Birth"`         // capitalised, as clients have always received it
+	Location      Location           `bson:"location" json:"location"`   // For geo queries
+	EmailVerified bool               `bson:"emailVerified" json:"emailVerified"`
+	VerifyToken   string             `bson:"verifyToken,omitempty" json:"-"`
+	VerifyExpires time.Time          `bson:"verifyTokenExpiresAt,omitempty" json:"-"`
+	VerifySentAt  time.Time          `bson:"verifySentAt,omitempty" json:"-"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"` // profile version, see the ETag on /profile
+	LastActiveAt  time.Time          `bson:"lastActiveAt,omitempty" json:"-"`
+
+	// Two-factor authentication (TOTP)
+	TwoFactorEnabled  bool     `bson:"twoFactorEnabled" json:"twoFactorEnabled"`
+	TOTPSecret        string   `bson:"totpSecret,omitempty" json:"-"`
+	TOTPPendingSecret string   `bson:"totpPendingSecret,omitempty" json:"-"` // awaiting confirmation
+	TOTPLastStep      int64    `bson:"totpLastStep,omitempty" json:"-"`      // last accepted step, blocks replays
+	RecoveryCodes     []string `bson:"recoveryCodes,omitempty" json:"-"`     // sha256 hashes of unused codes
+
+	// Social logins; users created through one have no password
+	Identities []ExternalIdentity `bson:"identities,omitempty" json:"identities,omitempty"`
+
+	ProfileDetails `bson:",inline"`
+
+	// 0-100, see the completeness package; kept up to date by the handlers that change a step
+	Completeness int `bson:"completeness" json:"-"`
+
+	// Free-text interests from before the catalog that the migration could not map
+	LegacyInterests []string `bson:"legacyInterests,omitempty" json:"-"`
+
+	// Who the user wants to see in discovery; nil until they save them
+	Preferences *DiscoveryPreferences `bson:"preferences,omitempty" json:"-"` // served by /preferences only
+
+	// Pause mode: out of discovery, matches keep working. No PausedUntil means paused until resumed.
+	PausedAt    *time.Time `bson:"pausedAt,omitempty" json:"pausedAt,omitempty"`
+	PausedUntil *time.Time `bson:"pausedUntil,omitempty" json:"pausedUntil,omitempty"`
+
+	// "" for everyone but staff, see RoleAdmin
+	Role string `bson:"role,omitempty" json:"role,omitempty"`
+
+	// Photos rejected by a moderator for breaking the rules. Reaching the strike limit flags the
+	// account, and a flagged account's uploads always wait for a moderator.
+	PhotoStrikes int        `bson:"photoStrikes,omitempty" json:"-"`
+	FlaggedAt    *time.Time `bson:"flaggedAt,omitempty" json:"-"`
+
+	// Account deletion: hidden right away, hard-deleted once PurgeAt passes
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"-"`
+	PurgeAt   *time.Time `bson:"purgeAt,omitempty" json:"-"`
 }
 
+// RoleAdmin may use the /api/admin routes, e.g. the photo moderation queue
+const RoleAdmin = "admin"
+
 // Hex returns the string version of the user's ObjectID
 func (u *User) Hex() string {
 	return u.ID.Hex()
 }
 
+// HasLocation reports whether the user ever shared a real location; new accounts start at [0, 0]
+func (u *User) HasLocation() bool {
+	c := u.Location.Coordinates
+	return len(c) == 2 && (c[0] != 0 || c[1] != 0)
+}
+
+// IsPaused reports whether the user's pause is in effect at t
+func (u *User) IsPaused(t time.Time) bool {
+	return u.PausedAt != nil && (u.PausedUntil == nil || u.PausedUntil.After(t))
+}
+
 type RegisterRequest struct {
 	Name      string    `json:"name"`
 	Email     string    `json:"email"`
@@ -47,5 +108,5 @@ type RegisterRequest struct {
 	Gender    string    `json:"gender"`
 	Birth     time.Time `json:"birth"`
 	Interests []string  `json:"interests"`
-	Location  Location  `json:"location"`
+	Location  *Location `json:"location"` // optional
 }
@@ -0,0 +1,137 @@
+// Package moderation decides whether uploaded photos go live, with a classifier first and human
+// moderators for whatever it can't decide
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+)
+
+// Classifier verdicts
+const (
+	VerdictApprove = "approve"
+	VerdictReject  = "reject"
+	VerdictReview  = "review" // can't tell, a moderator decides
+)
+
+// Decision is a classifier's answer for one photo
+type Decision struct {
+	Verdict string
+	Reason  string   // rejection reason ID, only with VerdictReject
+	Labels  []string // what was noticed, kept for moderators
+}
+
+// Input is what a classifier gets to look at: the card rendition plus the full size dimensions
+type Input struct {
+	MimeType string
+	Card     []byte
+	Width    int
+	Height   int
+}
+
+// Classifier screens a new photo. An error sends the photo to the moderation queue.
+type Classifier interface {
+	Classify(ctx context.Context, in Input) (Decision, error)
+}
+
+// ClassifierFromEnv picks MODERATION_CLASSIFIER: rules (default) or review, which sends every
+// photo to moderators
+func ClassifierFromEnv() (Classifier, error) {
+	switch name := getEnv("MODERATION_CLASSIFIER", "rules"); name {
+	case "rules":
+		return DefaultRules(), nil
+	case "review":
+		return ReviewAll{}, nil
+	default:
+		return nil, fmt.Errorf("unknown MODERATION_CLASSIFIER %q", name)
+	}
+}
+
+// ReviewAll decides nothing; every photo waits for a moderator
+type ReviewAll struct{}
+
+func (ReviewAll) Classify(ctx context.Context, in Input) (Decision, error) {
+	return Decision{Verdict: VerdictReview}, nil
+}
+
+// Rules is a local classifier for the obvious cases. It rejects photos that are too small, too
+// narrow or blank, sends photos with a lot of skin tones to moderators and approves the rest.
+type Rules struct {
+	MinSide   int     // shortest side of the full size, in pixels
+	MaxAspect float64 // longest side over shortest side
+	MinStdDev float64 // brightness spread below this counts as blank
+	MaxSkin   float64 // share of skin-toned pixels above this needs a moderator
+}
+
+func DefaultRules() Rules {
+	return Rules{MinSide: 200, MaxAspect: 3, MinStdDev: 6, MaxSkin: 0.45}
+}
+
+func (c Rules) Classify(ctx context.Context, in Input) (Decision, error) {
+	short, long := min(in.Width, in.Height), max(in.Width, in.Height)
+	if short < c.MinSide {
+		return Decision{Verdict: VerdictReject, Reason: ReasonLowQuality, Labels: []string{"too_small"}}, nil
+	}
+	if float64(long)/float64(short) > c.MaxAspect {
+		return Decision{Verdict: VerdictReject, Reason: ReasonLowQuality, Labels: []string{"aspect_ratio"}}, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(in.Card))
+	if err != nil {
+		return Decision{}, err
+	}
+	stdDev, skin := measure(img)
+	if stdDev < c.MinStdDev {
+		return Decision{Verdict: VerdictReject, Reason: ReasonLowQuality, Labels: []string{"blank"}}, nil
+	}
+	if skin > c.MaxSkin {
+		return Decision{Verdict: VerdictReview, Labels: []string{fmt.Sprintf("skin_tones_%.0f%%", skin*100)}}, nil
+	}
+	return Decision{Verdict: VerdictApprove}, nil
+}
+
+// measure returns the standard deviation of brightness (0-255) and the share of skin-toned pixels
+func measure(img image.Image) (stdDev, skin float64) {
+	b := img.Bounds()
+	var n, skinned int
+	var sum, sumSq float64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r16, g16, b16, _ := img.At(x, y).RGBA()
+			r, g, bl := float64(r16>>8), float64(g16>>8), float64(b16>>8)
+
+			lum := 0.299*r + 0.587*g + 0.114*bl
+			sum += lum
+			sumSq += lum * lum
+			if isSkin(r, g, bl) {
+				skinned++
+			}
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	mean := sum / float64(n)
+	return math.Sqrt(math.Max(0, sumSq/float64(n)-mean*mean)), float64(skinned) / float64(n)
+}
+
+// isSkin is the YCbCr range commonly used for skin detection (Chai & Ngan)
+func isSkin(r, g, b float64) bool {
+	cb := 128 - 0.168736*r - 0.331264*g + 0.5*b
+	cr := 128 + 0.5*r - 0.418688*g - 0.081312*b
+	return cb >= 77 && cb <= 127 && cr >= 133 && cr <= 173
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
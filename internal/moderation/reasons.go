@@ -0,0 +1,46 @@
+package moderation
+
+// Rejection reason IDs
+const (
+	ReasonNudity     = "nudity"
+	ReasonViolence   = "violence"
+	ReasonHate       = "hate"
+	ReasonNotYou     = "not_you"
+	ReasonLowQuality = "low_quality"
+	ReasonOther      = "other"
+)
+
+// Reason is why a photo was rejected, as the owner is told. Strike reasons count towards
+// flagging the account; honest mistakes don't.
+type Reason struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Strike  bool   `json:"strike"`
+}
+
+var Reasons = []Reason{
+	{ID: ReasonNudity, Message: "Nudity and sexual content aren't allowed.", Strike: true},
+	{ID: ReasonViolence, Message: "Violent or graphic content isn't allowed.", Strike: true},
+	{ID: ReasonHate, Message: "Hateful symbols and content aren't allowed.", Strike: true},
+	{ID: ReasonNotYou, Message: "Profile photos have to show you."},
+	{ID: ReasonLowQuality, Message: "The photo is too small, too narrow or blank."},
+	{ID: ReasonOther, Message: "The photo doesn't follow our community guidelines."},
+}
+
+func ReasonByID(id string) (Reason, bool) {
+	for _, r := range Reasons {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Reason{}, false
+}
+
+// ReasonIDs lists the values accepted for a rejection reason
+func ReasonIDs() []string {
+	ids := make([]string, 0, len(Reasons))
+	for _, r := range Reasons {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
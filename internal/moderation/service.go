@@ -0,0 +1,273 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ships-backend/internal/completeness"
+	"ships-backend/internal/mail"
+	"ships-backend/internal/models"
+	"ships-backend/internal/ws"
+)
+
+// DefaultStrikeLimit is how many strike rejections flag an account
+const DefaultStrikeLimit = 3
+
+var (
+	ErrNotFound   = errors.New("photo not found")
+	ErrNotPending = errors.New("photo is not waiting for review")
+	ErrRejected   = errors.New("photo is already rejected")
+)
+
+// Service runs new photos through the classifier and applies moderators' decisions
+type Service struct {
+	DB          *mongo.Database
+	Classifier  Classifier
+	Mail        *mail.Service
+	WS          *ws.Manager
+	StrikeLimit int
+}
+
+func NewService(db *mongo.Database, classifier Classifier, mailService *mail.Service, wsManager *ws.Manager, strikeLimit int) *Service {
+	return &Service{DB: db, Classifier: classifier, Mail: mailService, WS: wsManager, StrikeLimit: strikeLimit}
+}
+
+// NewServiceFromEnv reads MODERATION_CLASSIFIER (see ClassifierFromEnv) and MODERATION_STRIKE_LIMIT
+func NewServiceFromEnv(db *mongo.Database, mailService *mail.Service, wsManager *ws.Manager) (*Service, error) {
+	classifier, err := ClassifierFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := DefaultStrikeLimit
+	if v := getEnv("MODERATION_STRIKE_LIMIT", ""); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid MODERATION_STRIKE_LIMIT %q", v)
+		}
+	}
+
+	return NewService(db, classifier, mailService, wsManager, limit), nil
+}
+
+// Screen sets the status of a photo that is about to be stored. Re-uploads of a rejected photo
+// are rejected again, and nothing from a flagged account goes live without a moderator.
+func (s *Service) Screen(ctx context.Context, photo *models.UserPhoto, in Input) error {
+	var previous models.UserPhoto
+	err := s.DB.Collection("user_photos").FindOne(ctx, bson.M{
+		"userId":   photo.UserID,
+		"checksum": photo.Checksum,
+		"status":   models.PhotoRejected,
+	}).Decode(&previous)
+	if err == nil {
+		photo.Status = models.PhotoRejected
+		photo.Moderation = models.PhotoModeration{
+			Verdict: VerdictReject,
+			Reason:  previous.Moderation.Reason,
+			Labels:  []string{"previously_rejected"},
+		}
+		return nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	var owner models.User
+	if err := s.DB.Collection("users").FindOne(ctx, bson.M{"_id": photo.UserID}).Decode(&owner); err != nil {
+		return err
+	}
+
+	decision, err := s.Classifier.Classify(ctx, in)
+	if err != nil {
+		log.Printf("photo %s: classifier failed, queued for review: %v", photo.ID.Hex(), err)
+		decision = Decision{Verdict: VerdictReview, Labels: []string{"classifier_error"}}
+	}
+	if owner.FlaggedAt != nil && decision.Verdict == VerdictApprove {
+		decision = Decision{Verdict: VerdictReview, Labels: append(decision.Labels, "flagged_account")}
+	}
+
+	photo.Moderation = models.PhotoModeration{Verdict: decision.Verdict, Labels: decision.Labels}
+	switch decision.Verdict {
+	case VerdictApprove:
+		photo.Status = models.PhotoApproved
+	case VerdictReject:
+		photo.Status = models.PhotoRejected
+		photo.Moderation.Reason = decision.Reason
+	default:
+		photo.Status = models.PhotoPending
+	}
+	return nil
+}
+
+// Queue returns photos waiting for a moderator, oldest first
+func (s *Service) Queue(ctx context.Context, limit, skip int) ([]models.UserPhoto, error) {
+	cursor, err := s.DB.Collection("user_photos").Find(ctx,
+		bson.M{"status": models.PhotoPending},
+		options.Find().
+			SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+			SetLimit(int64(limit)).
+			SetSkip(int64(skip)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	photos := []models.UserPhoto{}
+	if err := cursor.All(ctx, &photos); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+// Approve puts a pending photo live
+func (s *Service) Approve(ctx context.Context, photoID, moderator primitive.ObjectID) (models.UserPhoto, error) {
+	now := time.Now()
+	photo, err := s.decide(ctx,
+		bson.M{"_id": photoID, "status": models.PhotoPending},
+		bson.M{
+			"status":                models.PhotoApproved,
+			"moderation.reviewedBy": moderator,
+			"moderation.reviewedAt": now,
+		},
+		ErrNotPending,
+	)
+	if err != nil {
+		return photo, err
+	}
+
+	s.refreshCompleteness(ctx, photo.UserID)
+	return photo, nil
+}
+
+// Reject hides a photo, pending or already live, and tells the owner why. Strike reasons count
+// towards flagging the account.
+func (s *Service) Reject(ctx context.Context, photoID, moderator primitive.ObjectID, reason Reason, note string) (models.UserPhoto, error) {
+	now := time.Now()
+	photo, err := s.decide(ctx,
+		bson.M{"_id": photoID, "status": bson.M{"$ne": models.PhotoRejected}},
+		bson.M{
+			"status":                models.PhotoRejected,
+			"moderation.reason":     reason.ID,
+			"moderation.note":       note,
+			"moderation.reviewedBy": moderator,
+			"moderation.reviewedAt": now,
+		},
+		ErrRejected,
+	)
+	if err != nil {
+		return photo, err
+	}
+
+	var owner models.User
+	if reason.Strike {
+		owner, err = s.strike(ctx, photo.UserID, now)
+	} else {
+		err = s.DB.Collection("users").FindOne(ctx, bson.M{"_id": photo.UserID}).Decode(&owner)
+	}
+	if err != nil {
+		// The photo is hidden either way; only the bookkeeping is missing
+		log.Printf("photo %s rejected, but the owner could not be updated: %v", photoID.Hex(), err)
+		return photo, nil
+	}
+
+	s.notifyRejected(owner, reason, note)
+	s.refreshCompleteness(ctx, photo.UserID)
+	return photo, nil
+}
+
+// Flagged lists accounts that reached the strike limit, most recent first
+func (s *Service) Flagged(ctx context.Context) ([]models.User, error) {
+	cursor, err := s.DB.Collection("users").Find(ctx,
+		bson.M{"flaggedAt": bson.M{"$exists": true}},
+		options.Find().SetSort(bson.D{{Key: "flaggedAt", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// decide applies set to the photo matching filter. When nothing matches it tells a missing photo
+// (ErrNotFound) from one in the wrong state (wrongState).
+func (s *Service) decide(ctx context.Context, filter, set bson.M, wrongState error) (models.UserPhoto, error) {
+	photos := s.DB.Collection("user_photos")
+
+	var photo models.UserPhoto
+	err := photos.FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&photo)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		n, countErr := photos.CountDocuments(ctx, bson.M{"_id": filter["_id"]})
+		if countErr != nil {
+			return photo, countErr
+		}
+		if n == 0 {
+			return photo, ErrNotFound
+		}
+		return photo, wrongState
+	}
+	return photo, err
+}
+
+// strike counts one more strike against the owner and flags the account at the limit
+func (s *Service) strike(ctx context.Context, userID primitive.ObjectID, now time.Time) (models.User, error) {
+	users := s.DB.Collection("users")
+
+	var owner models.User
+	err := users.FindOneAndUpdate(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$inc": bson.M{"photoStrikes": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&owner)
+	if err != nil {
+		return owner, err
+	}
+
+	if owner.PhotoStrikes >= s.StrikeLimit && owner.FlaggedAt == nil {
+		if _, err := users.UpdateOne(ctx,
+			bson.M{"_id": userID, "flaggedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"flaggedAt": now}},
+		); err != nil {
+			return owner, err
+		}
+		owner.FlaggedAt = &now
+		log.Printf("user %s flagged after %d photo strikes", userID.Hex(), owner.PhotoStrikes)
+	}
+	return owner, nil
+}
+
+func (s *Service) notifyRejected(owner models.User, reason Reason, note string) {
+	if s.WS != nil {
+		s.WS.SendTo(owner.Hex(), models.ChatMessagePayload{
+			Type: "photo_rejected",
+			Text: reason.Message,
+			Time: time.Now(),
+		})
+	}
+	if s.Mail != nil && owner.Email != "" {
+		s.Mail.SendAsync(owner.Email, "photo_rejected", map[string]any{
+			"Name":   owner.Name,
+			"Reason": reason.Message,
+			"Note":   note,
+		})
+	}
+}
+
+// refreshCompleteness rescores the owner, since only approved photos count
+func (s *Service) refreshCompleteness(ctx context.Context, userID primitive.ObjectID) {
+	if _, err := completeness.Recompute(ctx, s.DB, userID); err != nil {
+		log.Printf("completeness for user %s not updated: %v", userID.Hex(), err)
+	}
+}
@@ -0,0 +1,73 @@
+package oidc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ProviderConfig describes one OpenID Connect identity provider. The endpoint URLs are optional:
+// anything left empty is filled from the issuer's /.well-known/openid-configuration.
+type ProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	AuthURL      string
+	TokenURL     string
+	JWKSURL      string
+	Scopes       []string
+}
+
+// LoadConfigsFromEnv reads OIDC_PROVIDERS (e.g. "google,apple") and for each name the
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL, _AUTH_URL, _TOKEN_URL,
+// _JWKS_URL and _SCOPES variables.
+func LoadConfigsFromEnv() ([]ProviderConfig, error) {
+	var configs []ProviderConfig
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := ProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			AuthURL:      os.Getenv(prefix + "AUTH_URL"),
+			TokenURL:     os.Getenv(prefix + "TOKEN_URL"),
+			JWKSURL:      os.Getenv(prefix + "JWKS_URL"),
+			Scopes:       []string{"openid", "email", "profile"},
+		}
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			cfg.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			return nil, fmt.Errorf("OIDC provider %q needs %sISSUER, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+		}
+
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
+
+// ProvidersFromEnv builds a Provider for every configured OIDC_PROVIDERS entry, keyed by name
+func ProvidersFromEnv() (map[string]*Provider, error) {
+	configs, err := LoadConfigsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make(map[string]*Provider, len(configs))
+	for _, cfg := range configs {
+		providers[cfg.Name] = NewProvider(cfg)
+	}
+	return providers, nil
+}
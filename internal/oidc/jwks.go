@@ -0,0 +1,129 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minRefetchInterval stops a flood of tokens with unknown kids from hammering the IdP
+const minRefetchInterval = time.Minute
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keyCache holds the provider's signing keys, refetched when a token names a kid we haven't seen
+type keyCache struct {
+	url         string
+	client      *http.Client
+	mu          sync.Mutex
+	keys        map[string]any
+	lastFetched time.Time
+}
+
+func (c *keyCache) get(ctx context.Context, kid string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(c.lastFetched) < minRefetchInterval && c.keys != nil {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	keys, err := fetchJWKS(ctx, c.client, c.url)
+	c.lastFetched = time.Now()
+	if err != nil {
+		return nil, err
+	}
+	c.keys = keys
+
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+func fetchJWKS(ctx context.Context, client *http.Client, url string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks fetch failed: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			continue // skip key types we don't verify with
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+
+	return nil, errors.New("unsupported key type " + k.Kty)
+}
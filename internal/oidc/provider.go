@@ -0,0 +1,215 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Provider runs the authorization code + PKCE flow against one identity provider
+type Provider struct {
+	Config ProviderConfig
+	client *http.Client
+
+	discoverMu sync.Mutex
+	discovered bool
+	keys       *keyCache
+}
+
+// IDClaims are the ID token claims we use to find or create the local user
+type IDClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+type idTokenClaims struct {
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"` // bool, or "true"/"false" from Apple
+	Name          string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+func NewProvider(cfg ProviderConfig) *Provider {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &Provider{
+		Config: cfg,
+		client: client,
+		keys:   &keyCache{url: cfg.JWKSURL, client: client},
+	}
+}
+
+// discover fills any missing endpoint from the issuer's discovery document. Only a successful
+// discovery is kept, so an IdP that was down on first use is tried again on the next login.
+func (p *Provider) discover(ctx context.Context) error {
+	p.discoverMu.Lock()
+	defer p.discoverMu.Unlock()
+
+	if p.discovered || (p.Config.AuthURL != "" && p.Config.TokenURL != "" && p.Config.JWKSURL != "") {
+		return nil
+	}
+
+	wellKnown := strings.TrimRight(p.Config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var doc struct {
+		Issuer   string `json:"issuer"`
+		AuthURL  string `json:"authorization_endpoint"`
+		TokenURL string `json:"token_endpoint"`
+		JWKSURL  string `json:"jwks_uri"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("discovery failed: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return err
+	}
+	if doc.Issuer != p.Config.Issuer {
+		return fmt.Errorf("discovery issuer %q does not match %q", doc.Issuer, p.Config.Issuer)
+	}
+
+	if p.Config.AuthURL == "" {
+		p.Config.AuthURL = doc.AuthURL
+	}
+	if p.Config.TokenURL == "" {
+		p.Config.TokenURL = doc.TokenURL
+	}
+	if p.Config.JWKSURL == "" {
+		p.Config.JWKSURL = doc.JWKSURL
+		p.keys.url = doc.JWKSURL
+	}
+	p.discovered = true
+	return nil
+}
+
+// AuthCodeURL is where the user's browser goes to sign in
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.Config.ClientID)
+	v.Set("redirect_uri", p.Config.RedirectURL)
+	v.Set("scope", strings.Join(p.Config.Scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", codeChallenge)
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.Config.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.Config.AuthURL + sep + v.Encode(), nil
+}
+
+// Exchange trades the authorization code for tokens and returns the verified ID token claims
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*IDClaims, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.Config.RedirectURL)
+	form.Set("client_id", p.Config.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.Config.ClientSecret != "" {
+		form.Set("client_secret", p.Config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: %s %s %s", resp.Status, tokens.Error, tokens.Description)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	return p.VerifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// VerifyIDToken checks signature, issuer, audience, expiry and nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*IDClaims, error) {
+	var claims idTokenClaims
+	token, err := jwt.ParseWithClaims(raw, &claims,
+		func(t *jwt.Token) (interface{}, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.keys.get(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithIssuer(p.Config.Issuer),
+		jwt.WithAudience(p.Config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid id_token: %v", err)
+	}
+
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id_token has no sub")
+	}
+
+	verified := false
+	switch v := claims.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+
+	return &IDClaims{
+		Subject:       claims.Subject,
+		Email:         strings.TrimSpace(claims.Email),
+		EmailVerified: verified,
+		Name:          claims.Name,
+	}, nil
+}
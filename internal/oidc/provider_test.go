@@ -0,0 +1,173 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newStubServer serves a StubIdP under /dev/oidc, the way main.go mounts it in development
+func newStubServer(t *testing.T) (*httptest.Server, *StubIdP, *Provider) {
+	t.Helper()
+
+	var handler http.Handler = http.NotFoundHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	stub, provider, err := NewDevStub(srv.URL)
+	if err != nil {
+		t.Fatalf("NewDevStub: %v", err)
+	}
+	handler = stub
+	return srv, stub, provider
+}
+
+// authorize follows the provider's sign-in URL and returns the code and state sent to the callback
+func authorize(t *testing.T, authURL string) (code, state string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("callback URL: %v", err)
+	}
+	return callback.Query().Get("code"), callback.Query().Get("state")
+}
+
+func TestStubLoginFlow(t *testing.T) {
+	_, _, provider := newStubServer(t)
+	ctx := context.Background()
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", challenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	code, state := authorize(t, authURL+"&login_hint=ada@example.com")
+	if state != "state-1" {
+		t.Errorf("state = %q, want state-1", state)
+	}
+
+	claims, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Email != "ada@example.com" || !claims.EmailVerified || claims.Subject == "" {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	// Codes are single use
+	if _, err := provider.Exchange(ctx, code, verifier, "nonce-1"); err == nil {
+		t.Error("second exchange of the same code succeeded")
+	}
+}
+
+func TestStubRejectsBadExchange(t *testing.T) {
+	_, _, provider := newStubServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		verifier func(string) string
+		nonce    string
+	}{
+		{"wrong verifier", func(string) string { return "not-the-verifier" }, "nonce-1"},
+		{"wrong nonce", func(v string) string { return v }, "other-nonce"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, challenge, err := NewPKCE()
+			if err != nil {
+				t.Fatal(err)
+			}
+			authURL, err := provider.AuthCodeURL(ctx, "state", "nonce-1", challenge)
+			if err != nil {
+				t.Fatalf("AuthCodeURL: %v", err)
+			}
+			code, _ := authorize(t, authURL)
+
+			if _, err := provider.Exchange(ctx, code, tt.verifier(verifier), tt.nonce); err == nil {
+				t.Error("Exchange succeeded")
+			}
+		})
+	}
+}
+
+func TestDiscoveryRetriesAfterFailure(t *testing.T) {
+	var failures atomic.Int32
+	failures.Store(1)
+
+	var stub *StubIdP
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration") && failures.Add(-1) >= 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	var err error
+	stub, err = NewStubIdP(srv.URL+"/dev/oidc", "ships-dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the issuer is configured, so the endpoints come from discovery
+	provider := NewProvider(ProviderConfig{
+		Name:        "stub",
+		Issuer:      stub.Issuer,
+		ClientID:    stub.ClientID,
+		RedirectURL: srv.URL + "/callback",
+		Scopes:      []string{"openid", "email"},
+	})
+	ctx := context.Background()
+
+	if _, err := provider.AuthCodeURL(ctx, "state", "nonce", "challenge"); err == nil {
+		t.Fatal("AuthCodeURL succeeded while discovery was down")
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", "challenge")
+	if err != nil {
+		t.Fatalf("AuthCodeURL after recovery: %v", err)
+	}
+	if !strings.HasPrefix(authURL, stub.Issuer+"/authorize?") {
+		t.Errorf("authURL = %q", authURL)
+	}
+	if provider.Config.JWKSURL != stub.Issuer+"/jwks" {
+		t.Errorf("JWKSURL = %q", provider.Config.JWKSURL)
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	srv, stub, _ := newStubServer(t)
+
+	provider := NewProvider(ProviderConfig{
+		Issuer:   srv.URL + "/dev/oidc/", // trailing slash: not the issuer the stub reports
+		ClientID: stub.ClientID,
+	})
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge"); err == nil {
+		t.Error("AuthCodeURL accepted a discovery document for another issuer")
+	}
+}
@@ -0,0 +1,228 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// StubIdP is a tiny in-process OpenID Connect provider for local development and tests, where
+// Google and Apple aren't reachable. /authorize signs in whoever is named in login_hint without
+// asking, so it must never be mounted in production.
+type StubIdP struct {
+	Issuer   string
+	ClientID string
+
+	key   *rsa.PrivateKey
+	kid   string
+	mu    sync.Mutex
+	codes map[string]stubGrant
+}
+
+type stubGrant struct {
+	email       string
+	name        string
+	nonce       string
+	challenge   string
+	redirectURI string
+	expiresAt   time.Time
+}
+
+func NewStubIdP(issuer, clientID string) (*StubIdP, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &StubIdP{
+		Issuer:   strings.TrimRight(issuer, "/"),
+		ClientID: clientID,
+		key:      key,
+		kid:      "stub-" + randomHex(4),
+		codes:    map[string]stubGrant{},
+	}, nil
+}
+
+// ServeHTTP routes on the path suffix so the stub can be mounted under any prefix
+func (s *StubIdP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration"):
+		s.discovery(w)
+	case strings.HasSuffix(r.URL.Path, "/authorize"):
+		s.authorize(w, r)
+	case strings.HasSuffix(r.URL.Path, "/token"):
+		s.token(w, r)
+	case strings.HasSuffix(r.URL.Path, "/jwks"):
+		s.jwks(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *StubIdP) discovery(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                s.Issuer,
+		"authorization_endpoint":                s.Issuer + "/authorize",
+		"token_endpoint":                        s.Issuer + "/token",
+		"jwks_uri":                              s.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize immediately approves the login_hint email (default stub.user@example.com)
+func (s *StubIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != s.ClientID || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	email := q.Get("login_hint")
+	if email == "" {
+		email = "stub.user@example.com"
+	}
+
+	code := randomHex(16)
+	s.mu.Lock()
+	s.codes[code] = stubGrant{
+		email:       email,
+		name:        strings.Split(email, "@")[0],
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		redirectURI: q.Get("redirect_uri"),
+		expiresAt:   time.Now().Add(time.Minute),
+	}
+	s.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *StubIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		stubTokenError(w, "invalid_request")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	grant, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	switch {
+	case !ok || time.Now().After(grant.expiresAt):
+		stubTokenError(w, "invalid_grant")
+		return
+	case r.PostForm.Get("client_id") != s.ClientID:
+		stubTokenError(w, "invalid_client")
+		return
+	case r.PostForm.Get("redirect_uri") != grant.redirectURI:
+		stubTokenError(w, "invalid_grant")
+		return
+	case S256Challenge(r.PostForm.Get("code_verifier")) != grant.challenge:
+		stubTokenError(w, "invalid_grant")
+		return
+	}
+
+	sum := sha256.Sum256([]byte(grant.email))
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            s.Issuer,
+		"sub":            "stub-" + hex.EncodeToString(sum[:8]),
+		"aud":            s.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          grant.nonce,
+		"email":          grant.email,
+		"email_verified": true,
+		"name":           grant.name,
+	})
+	token.Header["kid"] = s.kid
+
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		stubTokenError(w, "server_error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": randomHex(16),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (s *StubIdP) jwks(w http.ResponseWriter) {
+	pub := s.key.PublicKey
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": s.kid,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func stubTokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewDevStub creates a StubIdP meant to be mounted at baseURL+"/dev/oidc" and the "stub"
+// provider pointing at it, with the callback on our own public OIDC route.
+func NewDevStub(baseURL string) (*StubIdP, *Provider, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	stub, err := NewStubIdP(baseURL+"/dev/oidc", "ships-dev")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provider := NewProvider(ProviderConfig{
+		Name:        "stub",
+		Issuer:      stub.Issuer,
+		ClientID:    stub.ClientID,
+		RedirectURL: baseURL + "/api/public/auth/oidc/stub/callback",
+		AuthURL:     stub.Issuer + "/authorize",
+		TokenURL:    stub.Issuer + "/token",
+		JWKSURL:     stub.Issuer + "/jwks",
+		Scopes:      []string{"openid", "email", "profile"},
+	})
+	return stub, provider, nil
+}
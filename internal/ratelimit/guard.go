@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"time"
+)
+
+// Policy decides how failures turn into delays and lockouts
+type Policy struct {
+	FreeAttempts int           // failures allowed before any backoff
+	BaseDelay    time.Duration // first backoff, doubled on every further failure
+	MaxDelay     time.Duration
+	LockoutAfter int // failures that trigger a lockout
+	LockoutFor   time.Duration
+	Window       time.Duration // failures older than this are forgotten
+}
+
+// Decision tells the caller whether an attempt may go ahead
+type Decision struct {
+	Allowed    bool
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+type Guard struct {
+	Store  AttemptStore
+	Policy Policy
+	Now    func() time.Time
+}
+
+func NewGuard(store AttemptStore, policy Policy) *Guard {
+	return &Guard{Store: store, Policy: policy, Now: time.Now}
+}
+
+// Check reports whether another attempt for key is allowed right now
+func (g *Guard) Check(key string) Decision {
+	now := g.Now()
+	a := g.Store.Get(key, now, g.Policy.Window)
+
+	if now.Before(a.LockedUntil) {
+		return Decision{Locked: true, RetryAfter: a.LockedUntil.Sub(now)}
+	}
+
+	if wait := a.LastFailure.Add(g.delay(a.Failures)).Sub(now); wait > 0 {
+		return Decision{RetryAfter: wait}
+	}
+
+	return Decision{Allowed: true}
+}
+
+// Fail records a failed attempt. lockedNow is true only for the failure that triggered the lockout.
+func (g *Guard) Fail(key string) (a Attempts, lockedNow bool) {
+	now := g.Now()
+	a = g.Store.RecordFailure(key, now, g.Policy.Window)
+
+	if g.Policy.LockoutAfter > 0 && a.Failures >= g.Policy.LockoutAfter && !now.Before(a.LockedUntil) {
+		a.LockedUntil = now.Add(g.Policy.LockoutFor)
+		g.Store.Lock(key, a.LockedUntil)
+		return a, true
+	}
+
+	return a, false
+}
+
+// Succeed clears the counters for key
+func (g *Guard) Succeed(key string) {
+	g.Store.Reset(key)
+}
+
+// delay is the exponential backoff owed after the given number of failures
+func (g *Guard) delay(failures int) time.Duration {
+	over := failures - g.Policy.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+
+	d := g.Policy.BaseDelay
+	for i := 1; i < over && d < g.Policy.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > g.Policy.MaxDelay {
+		d = g.Policy.MaxDelay
+	}
+	return d
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Attempts is the failure history kept for one key (an account, an IP, ...)
+type Attempts struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// AttemptStore keeps failure counters. MemoryStore works for a single process; a shared
+// implementation (Redis, Mongo) can be dropped in when we run more than one instance.
+type AttemptStore interface {
+	// Get returns the current counters, forgetting failures older than window
+	Get(key string, now time.Time, window time.Duration) Attempts
+	// RecordFailure adds one failure and returns the updated counters
+	RecordFailure(key string, now time.Time, window time.Duration) Attempts
+	// Lock blocks the key until the given time
+	Lock(key string, until time.Time)
+	// Reset forgets everything about the key
+	Reset(key string)
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*Attempts
+	writes  int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*Attempts)}
+}
+
+func (s *MemoryStore) Get(key string, now time.Time, window time.Duration) Attempts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return Attempts{}
+	}
+	if expired(entry, now, window) {
+		delete(s.entries, key)
+		return Attempts{}
+	}
+	return *entry
+}
+
+func (s *MemoryStore) RecordFailure(key string, now time.Time, window time.Duration) Attempts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || expired(entry, now, window) {
+		entry = &Attempts{}
+		s.entries[key] = entry
+	}
+	entry.Failures++
+	entry.LastFailure = now
+
+	// Sweep stale keys now and then so the map doesn't grow forever
+	s.writes++
+	if s.writes%1000 == 0 {
+		for k, e := range s.entries {
+			if expired(e, now, window) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	return *entry
+}
+
+func (s *MemoryStore) Lock(key string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &Attempts{}
+		s.entries[key] = entry
+	}
+	entry.LockedUntil = until
+}
+
+func (s *MemoryStore) Reset(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+func expired(a *Attempts, now time.Time, window time.Duration) bool {
+	return now.After(a.LockedUntil) && now.Sub(a.LastFailure) > window
+}
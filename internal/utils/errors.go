@@ -8,20 +8,44 @@ import (
 
 type ErrorResponse struct {
 	Error struct {
-		Message string `json:"message"`
-		Log     string `json:"log,omitempty"`
+		Code    string            `json:"code,omitempty"`
+		Message string            `json:"message"`
+		Fields  map[string]string `json:"fields,omitempty"` // per-field messages for validation errors
+		Log     string            `json:"log,omitempty"`
 	} `json:"error"`
 }
 
+const ErrCodeValidation = "validation_failed"
+
 func RespondWithError(w http.ResponseWriter, statusCode int, userMessage string, logMessage string) {
+	RespondWithErrorCode(w, statusCode, "", userMessage, logMessage)
+}
+
+// RespondWithErrorCode is RespondWithError plus a stable machine readable code the client can branch on
+func RespondWithErrorCode(w http.ResponseWriter, statusCode int, code string, userMessage string, logMessage string) {
 	log.Println("[API ERROR]", logMessage)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
 	response := ErrorResponse{}
+	response.Error.Code = code
 	response.Error.Message = userMessage
 	response.Error.Log = logMessage
 	log.Println("[API ERROR]", logMessage)
 	json.NewEncoder(w).Encode(response)
 }
+
+// RespondWithFieldErrors reports invalid input as 422 with one message per field, e.g. {"email": "must be a valid email address"}
+func RespondWithFieldErrors(w http.ResponseWriter, fields map[string]string) {
+	log.Println("[API ERROR]", "validation failed:", fields)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+
+	response := ErrorResponse{}
+	response.Error.Code = ErrCodeValidation
+	response.Error.Message = "Some fields are invalid"
+	response.Error.Fields = fields
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is an asymmetric JWT key. Private is nil for keys that are only trusted for verification.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// JWK is a single public key as published in /.well-known/jwks.json
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadSigningKeyFile reads a PEM encoded RSA or Ed25519 key. Private keys can sign, public keys can only verify.
+// When kid is empty the RFC 7638 thumbprint is used.
+func LoadSigningKeyFile(path, kid string) (*SigningKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	key := &SigningKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("%s: only RSA and Ed25519 keys are supported", path)
+	}
+
+	if key.ID == "" {
+		key.ID, err = thumbprint(key.JWK())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// JWK returns the public half of the key in JWK form
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return jwk
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint, which makes a stable default kid
+func thumbprint(jwk JWK) (string, error) {
+	var members string
+	switch jwk.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	default:
+		return "", errors.New("unsupported key type for thumbprint")
+	}
+
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// PublicJWKS lists every asymmetric key currently trusted for verification
+func PublicJWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range jwtKeys.verify {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
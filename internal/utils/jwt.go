@@ -2,50 +2,215 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"log"
 	"os"
+	"strings"
 	"time"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+const (
+	accessTokenTTL          = 72 * time.Hour
+	challengeAudienceSuffix = ":2fa"
+)
 
-func GenerateJWT(userID string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(72 * time.Hour).Unix(),
-	}
+// keyring holds the active signing key and every key still accepted for verification.
+// HS256 with JWT_SECRET is only used, for signing and verifying, when no asymmetric key is
+// configured; otherwise anyone holding the old shared secret could still mint tokens.
+type keyring struct {
+	signing    *SigningKey
+	verify     map[string]*SigningKey
+	hmacSecret []byte
+	issuer     string
+	audience   string
+}
+
+var jwtKeys = &keyring{
+	verify:     map[string]*SigningKey{},
+	hmacSecret: []byte(os.Getenv("JWT_SECRET")),
+	issuer:     "ships-backend",
+	audience:   "ships-app",
+}
+
+// Claims is what the API needs back out of a validated access token
+type Claims struct {
+	UserID    string
+	SessionID string
+	TokenID   string
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+type accessTokenClaims struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
 }
 
-func ValidateJWT(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Ensure token uses the expected signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+// InitJWT loads signing configuration from the environment:
+//
+//	JWT_SIGNING_KEY_FILE  PEM private key (RSA or Ed25519) used to sign new tokens
+//	JWT_SIGNING_KEY_ID    optional kid for it, defaults to its RFC 7638 thumbprint
+//	JWT_VERIFY_KEY_FILES  comma separated PEM keys still accepted during a rotation window
+//	JWT_ISSUER / JWT_AUDIENCE
+//	JWT_SECRET            HS256, only when JWT_SIGNING_KEY_FILE is unset
+func InitJWT() error {
+	ring := &keyring{
+		verify:     map[string]*SigningKey{},
+		hmacSecret: []byte(os.Getenv("JWT_SECRET")),
+		issuer:     getEnv("JWT_ISSUER", "ships-backend"),
+		audience:   getEnv("JWT_AUDIENCE", "ships-app"),
+	}
+
+	if path := os.Getenv("JWT_SIGNING_KEY_FILE"); path != "" {
+		key, err := LoadSigningKeyFile(path, os.Getenv("JWT_SIGNING_KEY_ID"))
+		if err != nil {
+			return err
+		}
+		if key.Private == nil {
+			return fmt.Errorf("%s: signing key must be a private key", path)
+		}
+		ring.signing = key
+		ring.verify[key.ID] = key
+	}
+
+	for _, path := range strings.Split(os.Getenv("JWT_VERIFY_KEY_FILES"), ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
 		}
-		return jwtSecret, nil
+		key, err := LoadSigningKeyFile(path, "")
+		if err != nil {
+			return err
+		}
+		ring.verify[key.ID] = key
+	}
+
+	if ring.signing == nil {
+		if len(ring.hmacSecret) == 0 {
+			return errors.New("set JWT_SIGNING_KEY_FILE or JWT_SECRET")
+		}
+		log.Println("⚠️ No JWT_SIGNING_KEY_FILE set, signing tokens with HS256")
+	} else {
+		ring.hmacSecret = nil
+	}
+
+	jwtKeys = ring
+	return nil
+}
+
+func GenerateJWT(userID, sessionID string) (string, error) {
+	now := time.Now()
+	claims := accessTokenClaims{
+		UserID:    userID,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    jwtKeys.issuer,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{jwtKeys.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+			ID:        GenerateRandomToken(16),
+		},
+	}
+
+	return jwtKeys.sign(claims)
+}
+
+// GenerateChallengeJWT issues the short-lived token that stands between a correct password and the
+// second factor. Its audience differs from access tokens so ValidateJWT never accepts it.
+func GenerateChallengeJWT(userID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return jwtKeys.sign(jwt.RegisteredClaims{
+		Issuer:    jwtKeys.issuer,
+		Subject:   userID,
+		Audience:  jwt.ClaimStrings{jwtKeys.audience + challengeAudienceSuffix},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		ID:        GenerateRandomToken(16),
 	})
+}
 
-	if err != nil || !token.Valid {
-		return "", errors.New("invalid token")
+// ValidateChallengeJWT returns the user ID a 2FA challenge token was issued for
+func ValidateChallengeJWT(tokenString string) (string, error) {
+	var claims jwt.RegisteredClaims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, jwtKeys.lookupKey,
+		jwt.WithValidMethods(jwtKeys.methods()),
+		jwt.WithIssuer(jwtKeys.issuer),
+		jwt.WithAudience(jwtKeys.audience+challengeAudienceSuffix),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid || claims.Subject == "" {
+		return "", errors.New("invalid challenge token")
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return "", errors.New("invalid token claims")
+	return claims.Subject, nil
+}
+
+func (k *keyring) sign(claims jwt.Claims) (string, error) {
+	if k.signing == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(k.hmacSecret)
 	}
 
-	userID, ok := claims["user_id"].(string)
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+	return token.SignedString(k.signing.Private)
+}
+
+// methods lists the algorithms accepted on incoming tokens; HS256 only while we sign with it
+func (k *keyring) methods() []string {
+	if k.signing == nil {
+		return []string{"RS256", "EdDSA", "HS256"}
+	}
+	return []string{"RS256", "EdDSA"}
+}
+
+// lookupKey picks the verification key for a token from its kid header
+func (k *keyring) lookupKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(k.hmacSecret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return k.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.verify[kid]
 	if !ok {
-		return "", errors.New("user_id not found in token")
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if key.Method.Alg() != token.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+func ValidateJWT(tokenString string) (*Claims, error) {
+	var claims accessTokenClaims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, jwtKeys.lookupKey,
+		jwt.WithValidMethods(jwtKeys.methods()),
+		jwt.WithIssuer(jwtKeys.issuer),
+		jwt.WithAudience(jwtKeys.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 
-	return userID, nil
+	if claims.Subject == "" || claims.Subject != claims.UserID {
+		return nil, errors.New("sub not found in token")
+	}
+
+	if claims.SessionID == "" {
+		return nil, errors.New("sid not found in token")
+	}
+
+	return &Claims{UserID: claims.Subject, SessionID: claims.SessionID, TokenID: claims.ID}, nil
 }
 
 // GenerateRandomToken creates a secure random token with n bytes (results in 2n hex characters)
@@ -58,3 +223,17 @@ func GenerateRandomToken(length int) string {
 	}
 	return hex.EncodeToString(bytes)
 }
+
+// HashToken returns the hex encoded sha256 of an opaque token so it can be stored and looked up
+// without keeping the token itself in the database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -1,7 +1,12 @@
 package utils
 
 import (
+	"log"
 	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
 )
 
 // GetLocalIP returns the first non-loopback local IP address
@@ -35,3 +40,69 @@ func GetLocalIP() string {
 
 	return ""
 }
+
+var (
+	trustedProxiesOnce sync.Once
+	trustedProxies     []*net.IPNet
+)
+
+// loadTrustedProxies reads TRUSTED_PROXIES, comma separated IPs or CIDRs of our own reverse
+// proxies / load balancers
+func loadTrustedProxies() {
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("TRUSTED_PROXIES: ignoring %q: %v", entry, err)
+			continue
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+}
+
+func isTrustedProxy(ip net.IP) bool {
+	trustedProxiesOnce.Do(loadTrustedProxies)
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClientIP returns the caller's IP. X-Forwarded-For is only believed when the connection comes
+// from a trusted proxy (TRUSTED_PROXIES); anyone else could put whatever they like in it. The
+// header is read from the right, skipping our own proxies, so a client can't prepend a fake hop.
+func GetClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remote := net.ParseIP(host)
+	if remote == nil || !isTrustedProxy(remote) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		if !isTrustedProxy(ip) {
+			return ip.String()
+		}
+	}
+	return host
+}
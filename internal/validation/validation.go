@@ -0,0 +1,234 @@
+// Package validation checks request payloads and collects one message per invalid field
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"ships-backend/internal/catalog"
+	"ships-backend/internal/models"
+)
+
+const (
+	MinAge            = 18
+	MaxAge            = 120
+	MinPasswordLength = 8
+	MaxPasswordLength = 72 // bcrypt ignores anything longer
+	MaxNameLength     = 50
+	MaxBioLength      = 500
+	MaxInterests      = 10
+	MaxDistanceKm     = 200
+
+	MinHeightCm           = 100
+	MaxHeightCm           = 250
+	MaxOccupationLength   = 60
+	MaxLanguages          = 5
+	MaxPrompts            = 3
+	MaxPromptAnswerLength = 200
+)
+
+var languageCode = regexp.MustCompile(`^[a-z]{2}$`)
+
+// Genders are the values accepted for User.Gender
+var Genders = []string{"male", "female", "non-binary", "other"}
+
+// Validator collects field errors. Only the first problem per field is kept.
+type Validator struct {
+	Errors map[string]string
+}
+
+func New() *Validator {
+	return &Validator{Errors: map[string]string{}}
+}
+
+// Valid reports whether no errors were recorded
+func (v *Validator) Valid() bool {
+	return len(v.Errors) == 0
+}
+
+// AddError records msg for field unless the field already has an error
+func (v *Validator) AddError(field, msg string) {
+	if _, exists := v.Errors[field]; !exists {
+		v.Errors[field] = msg
+	}
+}
+
+// Check records msg for field when ok is false
+func (v *Validator) Check(ok bool, field, msg string) {
+	if !ok {
+		v.AddError(field, msg)
+	}
+}
+
+func (v *Validator) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+func (v *Validator) MaxLength(field, value string, max int) {
+	v.Check(utf8.RuneCountInString(value) <= max, field, fmt.Sprintf("must be at most %d characters", max))
+}
+
+func (v *Validator) Name(field, value string) {
+	v.Required(field, value)
+	v.MaxLength(field, value, MaxNameLength)
+}
+
+func (v *Validator) Bio(field, value string) {
+	v.MaxLength(field, value, MaxBioLength)
+}
+
+// Email accepts a bare address like jane@example.com, without a display name
+func (v *Validator) Email(field, value string) {
+	v.Required(field, value)
+	addr, err := mail.ParseAddress(value)
+	v.Check(err == nil && addr.Address == value && strings.Contains(value[strings.LastIndex(value, "@")+1:], "."),
+		field, "must be a valid email address")
+}
+
+// Password needs a minimum length and at least one letter and one digit
+func (v *Validator) Password(field, value string) {
+	v.Required(field, value)
+	v.Check(len(value) >= MinPasswordLength, field, fmt.Sprintf("must be at least %d characters", MinPasswordLength))
+	v.Check(len(value) <= MaxPasswordLength, field, fmt.Sprintf("must be at most %d bytes", MaxPasswordLength))
+
+	var letter, digit bool
+	for _, c := range value {
+		switch {
+		case unicode.IsLetter(c):
+			letter = true
+		case unicode.IsDigit(c):
+			digit = true
+		}
+	}
+	v.Check(letter && digit, field, "must contain a letter and a digit")
+}
+
+// Birth requires a date that makes the user an adult
+func (v *Validator) Birth(field string, birth time.Time, now time.Time) {
+	v.Check(!birth.IsZero(), field, "is required")
+	v.Check(birth.Before(now), field, "must be in the past")
+	age := Age(birth, now)
+	v.Check(age >= MinAge, field, fmt.Sprintf("you must be at least %d", MinAge))
+	v.Check(age <= MaxAge, field, "must be a real birth date")
+}
+
+func (v *Validator) Gender(field, value string) {
+	v.Check(In(value, Genders...), field, "must be one of "+strings.Join(Genders, ", "))
+}
+
+// Interests takes catalog interest IDs such as "video_games"
+func (v *Validator) Interests(field string, interests []string) {
+	v.Check(len(interests) <= MaxInterests, field, fmt.Sprintf("at most %d interests", MaxInterests))
+
+	seen := map[string]bool{}
+	for _, id := range interests {
+		_, ok := catalog.InterestByID(id)
+		v.Check(ok, field, "unknown interest "+strconv.Quote(id))
+		v.Check(!seen[id], field, "must not contain duplicates")
+		seen[id] = true
+	}
+}
+
+// Location checks a GeoJSON point: [longitude, latitude] within range
+func (v *Validator) Location(field string, loc models.Location) {
+	v.Check(loc.Type == "Point", field+".type", `must be "Point"`)
+	if len(loc.Coordinates) != 2 {
+		v.AddError(field+".coordinates", "must be [longitude, latitude]")
+		return
+	}
+	lng, lat := loc.Coordinates[0], loc.Coordinates[1]
+	v.Check(lng >= -180 && lng <= 180, field+".coordinates", "longitude must be between -180 and 180")
+	v.Check(lat >= -90 && lat <= 90, field+".coordinates", "latitude must be between -90 and 90")
+}
+
+// Age returns the number of full years between birth and now
+func Age(birth, now time.Time) int {
+	years := now.Year() - birth.Year()
+	if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+		years--
+	}
+	return years
+}
+
+// In reports whether value is one of allowed
+func In(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Preferences checks discovery preferences; fields are reported as field.member
+func (v *Validator) Preferences(field string, p models.DiscoveryPreferences) {
+	for _, g := range p.Genders {
+		v.Gender(field+".genders", g)
+	}
+	v.Check(p.MinAge >= MinAge && p.MinAge <= MaxAge, field+".minAge", fmt.Sprintf("must be between %d and %d", MinAge, MaxAge))
+	v.Check(p.MaxAge >= MinAge && p.MaxAge <= MaxAge, field+".maxAge", fmt.Sprintf("must be between %d and %d", MinAge, MaxAge))
+	v.Check(p.MinAge <= p.MaxAge, field+".maxAge", "must not be below minAge")
+	v.Check(p.MaxDistanceKm >= 1 && p.MaxDistanceKm <= MaxDistanceKm, field+".maxDistanceKm",
+		fmt.Sprintf("must be between 1 and %d", MaxDistanceKm))
+	v.Interests(field+".dealbreakers", p.Dealbreakers)
+	v.Filters(field+".filters", p.Filters)
+}
+
+// Enum checks value against a catalog list
+func (v *Validator) Enum(field, value string, allowed []string) {
+	v.Check(In(value, allowed...), field, "must be one of "+strings.Join(allowed, ", "))
+}
+
+func (v *Validator) HeightCm(field string, cm int) {
+	v.Check(cm >= MinHeightCm && cm <= MaxHeightCm, field, fmt.Sprintf("must be between %d and %d", MinHeightCm, MaxHeightCm))
+}
+
+// Languages takes ISO 639-1 codes such as "en" or "pt"
+func (v *Validator) Languages(field string, codes []string) {
+	v.Check(len(codes) <= MaxLanguages, field, fmt.Sprintf("at most %d languages", MaxLanguages))
+	seen := map[string]bool{}
+	for _, code := range codes {
+		v.Check(languageCode.MatchString(code), field, "must be two-letter language codes like \"en\"")
+		v.Check(!seen[code], field, "must not contain duplicates")
+		seen[code] = true
+	}
+}
+
+// Prompts allows up to MaxPrompts answers to different catalog prompts
+func (v *Validator) Prompts(field string, prompts []models.ProfilePrompt) {
+	v.Check(len(prompts) <= MaxPrompts, field, fmt.Sprintf("at most %d prompts", MaxPrompts))
+	seen := map[string]bool{}
+	for _, p := range prompts {
+		_, ok := catalog.PromptByID(p.PromptID)
+		v.Check(ok, field, "unknown prompt "+strconv.Quote(p.PromptID))
+		v.Check(!seen[p.PromptID], field, "each prompt can be answered once")
+		v.Check(strings.TrimSpace(p.Answer) != "", field, "answers must not be empty")
+		v.Check(utf8.RuneCountInString(p.Answer) <= MaxPromptAnswerLength, field,
+			fmt.Sprintf("answers must be at most %d characters", MaxPromptAnswerLength))
+		seen[p.PromptID] = true
+	}
+}
+
+// Filters checks discovery filters against catalog.Filters
+func (v *Validator) Filters(field string, filters map[string][]string) {
+	for name, values := range filters {
+		spec, ok := catalog.Filters[name]
+		if !ok {
+			v.AddError(field+"."+name, "is not a filter")
+			continue
+		}
+		if spec.Values == nil {
+			v.Languages(field+"."+name, values)
+			continue
+		}
+		for _, value := range values {
+			v.Enum(field+"."+name, value, spec.Values)
+		}
+	}
+}
@@ -1,61 +1,233 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"ships-backend/internal/account"
+	"ships-backend/internal/blob"
+	"ships-backend/internal/export"
+	"ships-backend/internal/mail"
 	"ships-backend/internal/middlewares"
+	"ships-backend/internal/migrations"
+	"ships-backend/internal/models"
+	"ships-backend/internal/moderation"
+	"ships-backend/internal/oidc"
+	"ships-backend/internal/photourl"
+	"ships-backend/internal/ratelimit"
 	"ships-backend/internal/utils"
 	"ships-backend/internal/ws"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"ships-backend/internal/database"
 	"ships-backend/internal/handlers"
 )
 
 func main() {
 	database.InitMongoDB()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		blobs, err := blob.NewStoreFromEnv(database.MongoDB)
+		if err != nil {
+			log.Fatalf("Blob storage setup failed: %v", err)
+		}
+		migrate(migrations.Deps{DB: database.MongoDB, Blobs: blobs}, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		admin(database.MongoDB, os.Args[2:])
+		return
+	}
+	if err := utils.InitJWT(); err != nil {
+		log.Fatalf("JWT setup failed: %v", err)
+	}
 	db := database.MongoDB
 	wsManager := ws.NewManager()
-	handler := handlers.NewHandler(db, wsManager)
-	database.EnsureIndexes(db)
+	mailService, err := mail.NewServiceFromEnv()
+	if err != nil {
+		log.Fatalf("Mail setup failed: %v", err)
+	}
+	blobs, err := blob.NewStoreFromEnv(db)
+	if err != nil {
+		log.Fatalf("Blob storage setup failed: %v", err)
+	}
+	exports, err := export.NewServiceFromEnv(db, blobs)
+	if err != nil {
+		log.Fatalf("Export setup failed: %v", err)
+	}
+	photoURLs, err := photourl.NewSignerFromEnv()
+	if err != nil {
+		log.Fatalf("Photo URL setup failed: %v", err)
+	}
+	moderationService, err := moderation.NewServiceFromEnv(db, mailService, wsManager)
+	if err != nil {
+		log.Fatalf("Moderation setup failed: %v", err)
+	}
+	handler := handlers.NewHandler(db, wsManager, mailService, exports, blobs, photoURLs, moderationService)
+	if err := database.EnsureIndexes(db); err != nil {
+		log.Fatalf("Index setup failed: %v", err)
+	}
+	go account.NewPurger(db, blobs).Run(context.Background())
+	go account.NewResumer(db).Run(context.Background())
+	go exports.Run(context.Background())
 	log.Println("🚀 Server is running on :8080")
 	setupRoutes(handler)
 }
 
+// migrate handles `migrate` (apply pending migrations) and `migrate status`
+func migrate(d migrations.Deps, args []string) {
+	ctx := context.Background()
+	if len(args) > 0 && args[0] == "status" {
+		list, err := migrations.List(ctx, d.DB)
+		if err != nil {
+			log.Fatalf("Listing migrations failed: %v", err)
+		}
+		for _, m := range list {
+			state := "pending"
+			if m.AppliedAt != nil {
+				state = "applied " + m.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-30s %-28s %s\n", m.ID, state, m.Description)
+		}
+		return
+	}
+	if err := migrations.Run(ctx, d); err != nil {
+		log.Fatalf("Migrations failed: %v", err)
+	}
+	log.Println("✅ Migrations applied")
+}
+
+// admin handles `admin grant <email>` and `admin revoke <email>`
+func admin(db *mongo.Database, args []string) {
+	if len(args) != 2 || (args[0] != "grant" && args[0] != "revoke") {
+		log.Fatal("usage: admin grant|revoke <email>")
+	}
+
+	update := bson.M{"$set": bson.M{"role": models.RoleAdmin}}
+	if args[0] == "revoke" {
+		update = bson.M{"$unset": bson.M{"role": ""}}
+	}
+	res, err := db.Collection("users").UpdateOne(context.Background(),
+		bson.M{"email": strings.TrimSpace(args[1])}, update)
+	if err != nil {
+		log.Fatalf("Updating role failed: %v", err)
+	}
+	if res.MatchedCount == 0 {
+		log.Fatalf("No user with email %s", args[1])
+	}
+	log.Printf("✅ %s: admin role %s", args[1], map[string]string{"grant": "granted", "revoke": "revoked"}[args[0]])
+}
+
 func setupRoutes(h *handlers.Handler) *mux.Router {
 	r := mux.NewRouter()
 
 	// CORS wrapper
 	handler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:19006", "http://localhost:8081"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "If-Match", "If-None-Match", "Range"},
+		ExposedHeaders:   []string{"ETag", "Last-Modified", "Content-Range"},
 		AllowCredentials: true,
 	}).Handler(r)
 
+	r.HandleFunc("/.well-known/jwks.json", handlers.JWKSHandler()).Methods("GET")
+
 	// 🔓 Public routes
 	public := r.PathPrefix("/api/public").Subrouter()
-	public.HandleFunc("/auth/login", handlers.LoginHandler(h.DB)).Methods("POST")
-	public.HandleFunc("/auth/register", handlers.NewAuthHandler(h.DB).RegisterHandler()).Methods("POST")
-	public.HandleFunc("/verify-email", handlers.VerifyEmailHandler(h.DB)).Methods("GET")
+	oidcProviders, err := oidc.ProvidersFromEnv()
+	if err != nil {
+		log.Fatalf("OIDC setup failed: %v", err)
+	}
+	if os.Getenv("OIDC_DEV_STUB") == "true" {
+		// Local stand-in IdP for "Sign in with stub" when there is no outside network
+		stub, provider, err := oidc.NewDevStub(os.Getenv("PUBLIC_BASE_URL"))
+		if err != nil {
+			log.Fatalf("OIDC stub setup failed: %v", err)
+		}
+		r.PathPrefix("/dev/oidc").Handler(stub)
+		oidcProviders["stub"] = provider
+	}
+
+	authHandler := handlers.NewAuthHandler(h.DB, h.Mail, h.WSManager, ratelimit.NewMemoryStore(), oidcProviders)
+	public.HandleFunc("/auth/login", authHandler.LoginHandler()).Methods("POST")
+	public.HandleFunc("/auth/login/2fa", authHandler.TwoFactorLoginHandler()).Methods("POST")
+	public.HandleFunc("/auth/passwordless/start", authHandler.PasswordlessStartHandler()).Methods("POST")
+	public.HandleFunc("/auth/passwordless/verify", authHandler.PasswordlessVerifyHandler()).Methods("POST")
+	public.HandleFunc("/auth/passwordless/verify", authHandler.MagicLinkHandler()).Methods("GET")
+	public.HandleFunc("/auth/oidc/{provider}/start", authHandler.OIDCStartHandler()).Methods("GET")
+	public.HandleFunc("/auth/oidc/{provider}/callback", authHandler.OIDCCallbackHandler()).Methods("GET", "POST")
+	public.HandleFunc("/auth/refresh", handlers.RefreshTokenHandler(h.DB)).Methods("POST")
+	public.HandleFunc("/auth/register", authHandler.RegisterHandler()).Methods("POST")
+	public.HandleFunc("/auth/password/forgot", authHandler.ForgotPasswordHandler()).Methods("POST")
+	public.HandleFunc("/auth/password/reset", authHandler.ResetPasswordHandler()).Methods("POST")
+	public.HandleFunc("/auth/password/reset", authHandler.ResetPasswordLinkHandler()).Methods("GET")
+	public.HandleFunc("/verify-email", authHandler.VerifyEmailHandler()).Methods("GET")
+	public.HandleFunc("/export/download", h.DownloadExportHandler()).Methods("GET")
+	public.HandleFunc("/photos/{photoId}", h.SignedPhotoHandler()).Methods("GET")
+	public.HandleFunc("/catalog/profile", handlers.ProfileCatalogHandler()).Methods("GET")
+	public.HandleFunc("/catalog/interests", handlers.InterestCatalogHandler()).Methods("GET")
 
 	// 🔐 Authenticated routes
 	auth := r.PathPrefix("/api/auth").Subrouter()
-	auth.Use(middlewares.AuthMiddleware)
+	auth.Use(middlewares.AuthMiddleware(h.DB))
 	auth.HandleFunc("/profile", handlers.GetProfileHandler(h.DB)).Methods("GET")
 	auth.HandleFunc("/profile", handlers.UpdateProfileHandler(h.DB)).Methods("PUT")
+	auth.HandleFunc("/profile", handlers.PatchProfileHandler(h.DB)).Methods("PATCH")
+	auth.HandleFunc("/profile/completeness", handlers.ProfileCompletenessHandler(h.DB)).Methods("GET")
 	auth.HandleFunc("/logout", handlers.LogoutHandler(h.DB)).Methods("POST")
+	auth.HandleFunc("/logout-all", handlers.LogoutAllHandler(h.DB)).Methods("POST")
+	auth.HandleFunc("/sessions", handlers.ListSessionsHandler(h.DB)).Methods("GET")
+	auth.HandleFunc("/sessions/{sessionId}", handlers.RevokeSessionHandler(h.DB)).Methods("DELETE")
+	auth.HandleFunc("/verify-email/resend", authHandler.ResendVerificationHandler()).Methods("POST")
+	auth.HandleFunc("/2fa/setup", authHandler.SetupTwoFactorHandler()).Methods("POST")
+	auth.HandleFunc("/2fa/confirm", authHandler.ConfirmTwoFactorHandler()).Methods("POST")
+	auth.HandleFunc("/2fa/disable", authHandler.DisableTwoFactorHandler()).Methods("POST")
+	auth.HandleFunc("/account", authHandler.DeleteAccountHandler()).Methods("DELETE")
+	auth.HandleFunc("/preferences", h.GetPreferencesHandler()).Methods("GET")
+	auth.HandleFunc("/preferences", h.UpdatePreferencesHandler()).Methods("PUT")
+	auth.HandleFunc("/pause", h.PauseHandler()).Methods("POST")
+	auth.HandleFunc("/resume", h.ResumeHandler()).Methods("POST")
+	auth.HandleFunc("/export", h.RequestExportHandler()).Methods("POST")
+	auth.HandleFunc("/export/{exportId}", h.ExportStatusHandler()).Methods("GET")
 
 	// Other protected routes...
 	auth.Handle("/nearby-users", h.NearbyUsersHandler()).Methods("GET")
 	auth.Handle("/queue", h.SwipeQueueHandler()).Methods("GET")
-	auth.Handle("/swipe/{userId}", h.SwipeHandler()).Methods("POST")
+	auth.Handle("/users/{userId}", h.PublicProfileHandler()).Methods("GET")
+	auth.Handle("/users/{userId}/photos", h.GetUserPhotosHandler()).Methods("GET")
+	auth.Handle("/users/{userId}/photo", h.GetUserPhotoHandler()).Methods("GET")
+	auth.Handle("/photos", h.UploadPhotoHandler()).Methods("POST")
+	auth.Handle("/photos/order", h.UpdatePhotoOrderHandler()).Methods("PUT")
+	auth.Handle("/photos/{photoId}", h.DeletePhotoHandler()).Methods("DELETE")
+	auth.Handle("/blocks", h.ListBlocksHandler()).Methods("GET")
+	auth.Handle("/blocks/{userId}", h.BlockUserHandler()).Methods("POST")
+	auth.Handle("/blocks/{userId}", h.UnblockUserHandler()).Methods("DELETE")
+	auth.Handle("/messages/{matchId}", h.GetMessagesHandler()).Methods("GET")
+
+	// 📧 Routes that need a verified email
+	verified := auth.NewRoute().Subrouter()
+	verified.Use(middlewares.RequireVerifiedEmail(h.DB))
+	verified.Handle("/swipe/{userId}", h.SwipeHandler()).Methods("POST")
+	verified.Handle("/messages/{matchId}", h.SendMessageHandler()).Methods("POST")
+
+	// 🛡 Admin routes
+	adminRoutes := r.PathPrefix("/api/admin").Subrouter()
+	adminRoutes.Use(middlewares.AuthMiddleware(h.DB), middlewares.RequireAdmin(h.DB))
+	adminRoutes.Handle("/moderation/photos", h.ModerationQueueHandler()).Methods("GET")
+	adminRoutes.Handle("/moderation/photos/{photoId}/approve", h.ApprovePhotoHandler()).Methods("POST")
+	adminRoutes.Handle("/moderation/photos/{photoId}/reject", h.RejectPhotoHandler()).Methods("POST")
+	adminRoutes.Handle("/moderation/reasons", h.ModerationReasonsHandler()).Methods("GET")
+	adminRoutes.Handle("/moderation/flagged-users", h.FlaggedUsersHandler()).Methods("GET")
 
 	// WebSocket routes
 	ws := r.PathPrefix("/ws").Subrouter()
-	ws.Use(middlewares.AuthMiddleware)
+	ws.Use(middlewares.AuthMiddleware(h.DB))
 	ws.Handle("/", handlers.WebSocketHandler(h.WSManager)).Methods("GET")
 	ws.Handle("/chat", h.WebSocketChatHandler()).Methods("GET")
 